@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/cmd/tftest/logger"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/coverage"
+	"github.com/spf13/cobra"
+)
+
+// coverageRecordsDir is the directory in the module root tftest run --coverage records to
+var coverageRecordsDir = filepath.Join(".tftest", "coverage")
+
+var (
+	// Coverage command flags
+	coverageModuleRoot string
+	coverageRecords    string
+	coverageFormat     string
+	coverageReportJSON string
+	coverageFail       bool
+)
+
+// coverageCmd represents the coverage command
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report the module variables and outputs not exercised by the examples and tests",
+	Long: `Report which variables of the module the examples set and which outputs the tests read.
+
+The variables and outputs of the root module are read from its .tf files. A variable is tested when an example's
+module block sets it to a constant, or to an example variable set in terraform.tfvars, a *.auto.tfvars file or
+the TestConfig.ExtraVars of a test. An output is asserted when a test reads an example output that references it,
+through GetOutput, the Assert* functions, DecodeOutput or snapshots.
+
+The vars and outputs used by the tests are recorded by 'tftest run --coverage'. Without records, only the
+variables set by the examples themselves are reported and every output is reported as unasserted.
+
+Examples:
+  tftest run --coverage && tftest coverage   # Run the tests, then report the coverage
+  tftest coverage --format json              # Print the report as JSON
+  tftest coverage --report-json coverage.json --fail-untested  # Write a JSON report and fail on gaps`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runCoverage()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+
+	// Add flags to coverage command
+	coverageCmd.Flags().StringVar(&coverageModuleRoot, "module-root", ".", "Path to the root of the Terraform module")
+	coverageCmd.Flags().StringVar(&coverageRecords, "records", "", "Directory of the records written by tftest run --coverage (default: "+coverageRecordsDir+" in the module root)")
+	coverageCmd.Flags().StringVar(&coverageFormat, "format", "text", "Output format: text or json")
+	coverageCmd.Flags().StringVar(&coverageReportJSON, "report-json", "", "Also write the report as JSON to a file")
+	coverageCmd.Flags().BoolVar(&coverageFail, "fail-untested", false, "Exit with non-zero status if any variable is untested or output unasserted (default: false)")
+}
+
+// runCoverage prints the coverage report of the module
+func runCoverage() {
+	if coverageFormat != "text" && coverageFormat != "json" {
+		logger.Fatal("Invalid format %q, valid formats are text and json", coverageFormat)
+	}
+
+	absPath, err := filepath.Abs(coverageModuleRoot)
+	if err != nil {
+		logger.Fatal("Error resolving path: %v", err)
+	}
+	if !verifyDirectoryStructure(absPath) {
+		logger.Fatal("Invalid directory structure at %s", absPath)
+	}
+
+	recordsDir := coverageRecords
+	if recordsDir == "" {
+		recordsDir = filepath.Join(absPath, coverageRecordsDir)
+	}
+	records, err := coverage.ReadRecords(recordsDir)
+	if err != nil {
+		logger.Fatal("Error reading coverage records: %v", err)
+	}
+	if len(records) == 0 {
+		logger.Warn("No coverage records found in %s, run 'tftest run --coverage' to include the vars and outputs used by the tests", recordsDir)
+	}
+
+	report, err := coverage.Analyze(absPath, records)
+	if err != nil {
+		logger.Fatal("Error analyzing coverage: %v", err)
+	}
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Fatal("Error encoding report: %v", err)
+	}
+	if coverageFormat == "json" {
+		fmt.Println(string(content))
+	} else {
+		fmt.Print(report.String())
+	}
+
+	if coverageReportJSON != "" {
+		if err := os.WriteFile(coverageReportJSON, content, 0644); err != nil {
+			logger.Fatal("Error writing report: %v", err)
+		}
+		logger.Info("Coverage report written to %s", coverageReportJSON)
+	}
+
+	if coverageFail && (len(report.UntestedVariables) > 0 || len(report.UnassertedOutputs) > 0) {
+		logger.Error("%d variables are untested and %d outputs are unasserted", len(report.UntestedVariables), len(report.UnassertedOutputs))
+		os.Exit(1)
+	}
+}
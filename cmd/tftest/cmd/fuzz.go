@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/cmd/tftest/logger"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Fuzz-vars command flags
+	fuzzModuleRoot  string
+	fuzzExamplePath string
+	fuzzVariables   []string
+	fuzzRandomCases int
+	fuzzSeed        int64
+	fuzzLargeSize   int
+	fuzzBinary      string
+	fuzzReportJSON  string
+)
+
+// fuzzVarsCmd represents the fuzz-vars command
+var fuzzVarsCmd = &cobra.Command{
+	Use:   "fuzz-vars",
+	Short: "Plan examples with generated variable inputs to find crashes and unhelpful errors",
+	Long: `Generate boundary and random inputs for the variables of the module and plan every input through each example.
+
+Inputs are generated from the type constraints, defaults and validation blocks of the module each example calls
+with a local source, e.g. ../../ (or of the example itself if it calls none):
+nulls, values of the wrong type, empty and oversized strings, lists and maps, values around the limits of
+validation conditions and seeded random values. Only one variable is changed at a time, the others keep the
+values from terraform.tfvars or their defaults. Nothing is applied.
+
+An input is reported if the plan crashes, or if it passes the type constraint and validation blocks but the
+plan fails with an unrelated error, meaning a validation block should have rejected it with a clear message.
+Inputs are passed through the example variable a module variable is set to, e.g. name = var.bucket_name, and
+variables the example sets otherwise are planned against the module directly.
+
+Examples:
+  tftest fuzz-vars                                # Fuzz the variables of every example
+  tftest fuzz-vars --example-path basic           # Fuzz the variables of the basic example
+  tftest fuzz-vars --vars bucket_name --random 20 # Fuzz one variable with more random inputs
+  tftest fuzz-vars --report-json fuzz.json        # Write all results to a JSON file`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runFuzzVars()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fuzzVarsCmd)
+
+	// Add flags to fuzz-vars command
+	fuzzVarsCmd.Flags().StringVar(&fuzzModuleRoot, "module-root", ".", "Path to the root of the Terraform module")
+	fuzzVarsCmd.Flags().StringVar(&fuzzExamplePath, "example-path", "", "Specific example to fuzz (leave empty to fuzz all)")
+	fuzzVarsCmd.Flags().StringSliceVar(&fuzzVariables, "vars", nil, "Variables of the module to fuzz (default: all of them)")
+	fuzzVarsCmd.Flags().IntVar(&fuzzRandomCases, "random", 3, "Number of random inputs per variable (0 disables them)")
+	fuzzVarsCmd.Flags().Int64Var(&fuzzSeed, "seed", 1, "Seed of the random inputs, to reproduce a run")
+	fuzzVarsCmd.Flags().IntVar(&fuzzLargeSize, "large-size", 1000, "Length of oversized strings, lists and maps")
+	fuzzVarsCmd.Flags().StringVar(&fuzzBinary, "binary", "", "CLI to plan with: terraform, tofu or a path to a binary (default: terraform)")
+	fuzzVarsCmd.Flags().StringVar(&fuzzReportJSON, "report-json", "", "Write the results of all inputs to a JSON file")
+}
+
+// runFuzzVars fuzzes the variables of the selected examples and exits with 1 if any input is reported
+func runFuzzVars() {
+	absPath, err := filepath.Abs(fuzzModuleRoot)
+	if err != nil {
+		logger.Fatal("Error resolving path: %v", err)
+	}
+
+	names := []string{fuzzExamplePath}
+	if fuzzExamplePath == "" {
+		names, err = exampleNames(absPath)
+		if err != nil {
+			logger.Fatal("Error listing examples: %v", err)
+		}
+		if len(names) == 0 {
+			logger.Fatal("No examples found in %s", filepath.Join(absPath, "examples"))
+		}
+	}
+
+	// Zero means the default in FuzzOptions, while --random 0 disables random inputs
+	if fuzzRandomCases == 0 {
+		fuzzRandomCases = -1
+	}
+	options := testctx.FuzzOptions{
+		Variables:   fuzzVariables,
+		RandomCases: fuzzRandomCases,
+		Seed:        fuzzSeed,
+		LargeSize:   fuzzLargeSize,
+	}
+
+	var reports []testctx.FuzzReport
+	findings := 0
+	for _, name := range names {
+		exampleDir := filepath.Join(absPath, "examples", name)
+		if _, err := os.Stat(exampleDir); os.IsNotExist(err) {
+			logger.Fatal("Example directory not found: %s", exampleDir)
+		}
+
+		m, err := testctx.LoadManifest(exampleDir)
+		if err != nil {
+			logger.Fatal("Error reading manifest of example %s: %v", name, err)
+		}
+		if m.Skip.Reason != "" {
+			logger.Info("Skipping example %s: %s", name, m.Skip.Reason)
+			continue
+		}
+		if m.Skip.PlanOnly != "" {
+			logger.Info("Skipping example %s, which cannot be planned without an apply: %s", name, m.Skip.PlanOnly)
+			continue
+		}
+
+		logger.Info("Fuzzing variables of example %s", name)
+		t := &cliT{name: "fuzz-vars/" + name}
+		config := testctx.TestConfig{Name: name, Binary: fuzzBinary, Workspace: testctx.WorkspaceIsolated}
+		report, err := testctx.FuzzVariablesE(t, exampleDir, config, options)
+		if err != nil {
+			logger.Fatal("Failed to fuzz variables of %s: %v", name, err)
+		}
+		report.Example = name
+		reports = append(reports, report)
+
+		findings += len(report.Findings())
+		if len(report.Findings()) > 0 {
+			logger.Error("%s", report)
+		} else {
+			logger.Info("%s", report)
+		}
+	}
+
+	if fuzzReportJSON != "" {
+		content, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			logger.Fatal("Error encoding report: %v", err)
+		}
+		if err := os.WriteFile(fuzzReportJSON, content, 0644); err != nil {
+			logger.Fatal("Error writing report: %v", err)
+		}
+		logger.Info("Fuzzing report written to %s", fuzzReportJSON)
+	}
+
+	if findings > 0 {
+		logger.Error("%d inputs crashed the plan or were rejected with an unhelpful error", findings)
+		os.Exit(1)
+	}
+	logger.Info("No crashes or unhelpful errors found 🎉")
+}
+
+// exampleNames lists the example directories of a module
+func exampleNames(moduleRoot string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(moduleRoot, "examples"))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
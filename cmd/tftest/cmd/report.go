@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/cmd/tftest/logger"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/results"
+	"github.com/spf13/cobra"
+)
+
+// lastResultsFile is the file in the module root tftest run saves the results of the last run to
+var lastResultsFile = filepath.Join(".tftest", "results.json")
+
+var (
+	// Report command flags
+	reportModuleRoot string
+	reportResults    string
+	reportHTML       string
+	reportTitle      string
+)
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Render the results of a test run as a static HTML report",
+	Long: `Render the results of a test run as a single static HTML file that can be viewed offline.
+
+The report has a collapsible section per example and test, filtering by status, and for each test its failure
+messages, the plan diffs of failed idempotency checks, the durations of the Terraform phases, the benchmarks it
+ran and its full output. Styles and scripts are embedded in the file.
+
+The results are read from the last 'tftest run' in the module root, or with --results from a file written by
+'tftest run --report-json' or a 'go test -json' log.
+
+Examples:
+  tftest run && tftest report --html report.html  # Render the results of the last run
+  tftest report --html report.html --results results.json  # Render results written by --report-json
+  go test ./tests/... -json > run.jsonl; tftest report --html report.html --results run.jsonl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runReport()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	// Add flags to report command
+	reportCmd.Flags().StringVar(&reportModuleRoot, "module-root", ".", "Path to the root of the Terraform module")
+	reportCmd.Flags().StringVar(&reportResults, "results", "", "Results written by tftest run --report-json or go test -json (default: "+lastResultsFile+" in the module root)")
+	reportCmd.Flags().StringVar(&reportHTML, "html", "", "Write the HTML report to this file (required)")
+	reportCmd.Flags().StringVar(&reportTitle, "title", "", "Title of the report (default: tftest results)")
+}
+
+// runReport renders the results of a test run as HTML
+func runReport() {
+	if reportHTML == "" {
+		logger.Fatal("--html is required")
+	}
+
+	path := reportResults
+	if path == "" {
+		absPath, err := filepath.Abs(reportModuleRoot)
+		if err != nil {
+			logger.Fatal("Error resolving path: %v", err)
+		}
+		path = filepath.Join(absPath, lastResultsFile)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Fatal("Error reading test results: %v, run 'tftest run' first or pass --results", err)
+	}
+	defer file.Close()
+
+	report, err := results.ReadReport(file)
+	if err != nil {
+		logger.Fatal("Error reading test results from %s: %v", path, err)
+	}
+
+	var content bytes.Buffer
+	if err := report.HTML(&content, results.HTMLOptions{Title: reportTitle, Generated: time.Now()}); err != nil {
+		logger.Fatal("Error rendering HTML report: %v", err)
+	}
+	if err := os.WriteFile(reportHTML, content.Bytes(), 0644); err != nil {
+		logger.Fatal("Error writing HTML report: %v", err)
+	}
+	logger.Info("HTML report of %d examples and %d tests written to %s", report.Summary.Examples, report.Summary.Tests, reportHTML)
+}
@@ -1,22 +1,49 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/caylent-solutions/terraform-terratest-framework/cmd/tftest/logger"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/matrix"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/results"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
 	"github.com/spf13/cobra"
 )
 
+// defaultTestTimeout is the default -timeout of go test
+const defaultTestTimeout = 10 * time.Minute
+
 var (
 	// Run command flags
 	moduleRoot       string
-	examplePath      string
+	examplePaths     []string
+	excludeExamples  []string
+	tags             []string
+	runPattern       string
 	commonOnly       bool
 	parallelFixtures bool
 	parallelTests    bool
+	planOnly         bool
+	skipDestroy      bool
+	skipStages       []string
+	onlyStages       []string
+	binary           string
+	tfVersions       []string
+	tfVersionsFile   string
+	tfCacheDir       string
+	updateSnapshots  bool
+	recordCoverage   bool
+	reportJSON       string
+	junitReport      string
 )
 
 // runCmd represents the run command
@@ -28,10 +55,24 @@ var runCmd = &cobra.Command{
 Examples:
   tftest run                     # Run all tests in the current directory
   tftest run --example-path vpc  # Run tests for the vpc example
+  tftest run --example-path vpc,eks  # Run tests for the vpc and eks examples
+  tftest run --exclude-example eks   # Run all tests except those of the eks example
+  tftest run --tags smoke        # Run the tests of the examples tagged smoke in their tftest.yaml
+  tftest run --run 'TestOutputs/.*'  # Only run the tests matching the pattern (go test -run)
   tftest run --common            # Run only common tests
   tftest run --module-root /path/to/terraform-module  # Run all tests in the specified module
   tftest run --parallel-fixtures=true   # Run test fixtures in parallel
   tftest run --parallel-tests=true     # Run tests within fixtures in parallel
+  tftest run --plan-only         # Only run init and plan, no resources are created
+  tftest run --skip-destroy      # Keep the resources and persist the test context for later runs
+  tftest run --only validate     # Re-run the assertions against the resources kept by --skip-destroy
+  tftest run --only destroy      # Destroy the resources kept by --skip-destroy
+  tftest run --binary tofu       # Run the examples with OpenTofu instead of Terraform
+  tftest run --terraform-versions 1.5.7,1.9.0  # Run the examples once per Terraform version
+  tftest run --update-snapshots  # Create or rewrite the snapshots compared by AssertMatchesSnapshot
+  tftest run --coverage          # Record the vars and outputs used by the tests for tftest coverage
+  tftest run --report-json results.json  # Write the results per example and test as JSON
+  tftest run --junit report.xml  # Write the results as JUnit XML for CI systems
 
 This command expects a specific directory structure:
 - Examples in the 'examples/' directory
@@ -48,10 +89,25 @@ func init() {
 
 	// Add flags to run command
 	runCmd.Flags().StringVar(&moduleRoot, "module-root", ".", "Path to the root of the Terraform module (runs all tests)")
-	runCmd.Flags().StringVar(&examplePath, "example-path", "", "Specific example to test (leave empty to test all)")
+	runCmd.Flags().StringSliceVar(&examplePaths, "example-path", nil, "Examples to test, repeated or comma-separated (leave empty to test all)")
+	runCmd.Flags().StringSliceVar(&excludeExamples, "exclude-example", nil, "Examples to leave out, repeated or comma-separated")
+	runCmd.Flags().StringSliceVar(&tags, "tags", nil, "Only test the examples declaring one of these tags in examples/<name>/"+manifest.FileName)
+	runCmd.Flags().StringVar(&runPattern, "run", "", "Only run the tests and subtests matching this regular expression (passed to go test -run)")
 	runCmd.Flags().BoolVar(&commonOnly, "common", false, "Run only common tests")
 	runCmd.Flags().BoolVar(&parallelFixtures, "parallel-fixtures", false, "Run test fixtures in parallel (default: false)")
 	runCmd.Flags().BoolVar(&parallelTests, "parallel-tests", false, "Run tests within each fixture in parallel (default: false)")
+	runCmd.Flags().BoolVar(&planOnly, "plan-only", false, "Only run init and plan for each example, without apply/destroy (default: false)")
+	runCmd.Flags().BoolVar(&skipDestroy, "skip-destroy", false, "Skip the destroy stage and persist the test context (same as --skip destroy)")
+	runCmd.Flags().StringSliceVar(&skipStages, "skip", nil, "Lifecycle stages to skip (init, apply, idempotency, validate, destroy)")
+	runCmd.Flags().StringVar(&binary, "binary", "", "CLI to run the examples with: terraform, tofu or a path to a binary (default: terraform)")
+	runCmd.Flags().StringSliceVar(&onlyStages, "only", nil, "Only run these lifecycle stages (init, apply, idempotency, validate, destroy)")
+	runCmd.Flags().StringSliceVar(&tfVersions, "terraform-versions", nil, "Run the examples once per Terraform version, using binaries from --terraform-cache-dir")
+	runCmd.Flags().StringVar(&tfVersionsFile, "terraform-versions-file", "", "File listing the Terraform versions to run (default: "+testctx.VersionsFile+" in the module root, if present)")
+	runCmd.Flags().BoolVar(&updateSnapshots, "update-snapshots", false, "Create or rewrite the snapshots compared by AssertMatchesSnapshot instead of comparing them (default: false)")
+	runCmd.Flags().StringVar(&reportJSON, "report-json", "", "Write the results per example and test, with durations and output, to a JSON file")
+	runCmd.Flags().StringVar(&junitReport, "junit", "", "Write the results as JUnit XML, with a testsuite per example directory and a testcase per test")
+	runCmd.Flags().BoolVar(&recordCoverage, "coverage", false, "Record the vars passed to the examples and the outputs read by the tests for tftest coverage (default: false)")
+	runCmd.Flags().StringVar(&tfCacheDir, "terraform-cache-dir", "", "Directory holding the Terraform binaries of each version (default: ~/.tftest/terraform)")
 }
 
 // runTests executes the tests based on the provided flags
@@ -73,8 +129,8 @@ func runTests() {
 		os.Exit(1)
 	}
 
-	// If specific example, verify it exists
-	if examplePath != "" {
+	// If specific examples, verify they exist
+	for _, examplePath := range examplePaths {
 		exampleDir := filepath.Join(absPath, "examples", examplePath)
 		testDir := filepath.Join(absPath, "tests", examplePath)
 
@@ -95,17 +151,88 @@ func runTests() {
 		}
 	}
 
+	// Validate the lifecycle stage flags
+	if skipDestroy {
+		skipStages = append(skipStages, string(testctx.StageDestroy))
+	}
+	if len(skipStages) > 0 && len(onlyStages) > 0 {
+		logger.Fatal("--skip and --only cannot be used together")
+	}
+	for _, stages := range [][]string{skipStages, onlyStages} {
+		if _, err := testctx.ParseStages(strings.Join(stages, ",")); err != nil {
+			logger.Fatal("Invalid stage: %v", err)
+		}
+	}
+
+	// Resolve the Terraform versions of a version matrix run
+	versions := resolveTerraformVersions(absPath)
+	if len(versions) > 0 && binary != "" {
+		logger.Fatal("--binary cannot be used together with a Terraform version matrix")
+	}
+	binaries := resolveVersionBinaries(versions)
+
+	// Select the examples to run by name and tag
+	filter := manifest.Filter{Include: examplePaths, Exclude: excludeExamples, Tags: tags}
+	var selected []string
+	if !filter.Empty() {
+		selected, err = manifest.Select(absPath, filter)
+		if err != nil {
+			logger.Fatal("Error selecting examples: %v", err)
+		}
+		if len(selected) == 0 {
+			logger.Fatal("No examples match the selection")
+		}
+		if dependencies := addedDependencies(selected, examplePaths); len(dependencies) > 0 {
+			logger.Info("Including the examples the selection depends on: %s", strings.Join(dependencies, ", "))
+		}
+	}
+
+	// Read the manifests of the examples that run, for their skip reasons and timeouts
+	runSet := selected
+	if runSet == nil {
+		if runSet, err = exampleNames(absPath); err != nil {
+			logger.Fatal("Error reading examples: %v", err)
+		}
+	}
+	manifests := readManifests(absPath, runSet)
+	timeout := logManifests(runSet, manifests)
+
 	// Build the test command
-	testPath := "./tests/..."
-	if examplePath != "" {
-		testPath = fmt.Sprintf("./tests/%s/...", examplePath)
-		logger.Info("Running tests for example: %s", examplePath)
+	testPaths := []string{"./tests/..."}
+	if len(examplePaths) > 0 {
+		testPaths = examplePackages(absPath, selected)
+		logger.Info("Running tests for examples: %s", strings.Join(selected, ", "))
 	} else if commonOnly {
-		testPath = "./tests/common/..."
+		testPaths = []string{"./tests/common/..."}
 		logger.Info("Running common tests")
+	} else if selected != nil {
+		testPaths = append(examplePackages(absPath, selected), sharedPackages(absPath)...)
+		logger.Info("Running tests for examples: %s", strings.Join(selected, ", "))
 	} else {
 		logger.Info("Running all tests")
 	}
+	if len(testPaths) == 0 {
+		logger.Fatal("No test directories found for examples: %s", strings.Join(selected, ", "))
+	}
+
+	// Run the test packages of examples that depend on each other in stages, in dependency order
+	plan := testPlan{stages: [][]string{testPaths}}
+	if !commonOnly {
+		plan = planTests(absPath, testPaths, runSet, manifests, len(examplePaths) == 0)
+	}
+	if len(plan.stages) > 1 {
+		stages := make([]string, len(plan.stages))
+		for i, stage := range plan.stages {
+			stages[i] = strings.Join(stage, " ")
+		}
+		logger.Info("Running the examples in dependency order: %s", strings.Join(stages, " -> "))
+	}
+	if len(tags) > 0 {
+		logger.Info("Selecting examples tagged: %s", strings.Join(tags, ", "))
+	}
+	if runPattern != "" {
+		logger.Info("Only running tests matching: %s", runPattern)
+	}
 
 	logger.Info("Module root: %s", absPath)
 	if !parallelFixtures {
@@ -118,14 +245,38 @@ func runTests() {
 	} else {
 		logger.Info("Running tests within fixtures in parallel")
 	}
+	if planOnly {
+		logger.Info("Running in plan-only mode, no resources will be created")
+	}
+	if binary != "" {
+		logger.Info("Running examples with %s", binary)
+	}
+	if updateSnapshots {
+		logger.Info("Updating snapshots")
+	}
+	if len(skipStages) > 0 {
+		logger.Info("Skipping stages: %s", strings.Join(skipStages, ", "))
+	}
+	if len(onlyStages) > 0 {
+		logger.Info("Only running stages: %s", strings.Join(onlyStages, ", "))
+	}
+	if recordCoverage {
+		logger.Info("Recording coverage to %s", filepath.Join(absPath, coverageRecordsDir))
+	}
 	logger.Info("Starting tests...")
 
-	// Run the tests
-	args := []string{"test", testPath, "-v"}
+	// Run the tests, reading the results from the go test -json event stream
+	flags := []string{"-json"}
+	if runPattern != "" {
+		flags = append(flags, "-run", runPattern)
+	}
+	if timeout > 0 {
+		flags = append(flags, "-timeout", timeout.String())
+	}
 
 	// Add -p 1 flag if parallelFixtures is false to disable parallel execution of test fixtures
 	if !parallelFixtures {
-		args = append(args, "-p", "1")
+		flags = append(flags, "-p", "1")
 	}
 
 	// Set environment variable to control parallelism within test fixtures
@@ -135,18 +286,428 @@ func runTests() {
 		os.Setenv("TERRATEST_DISABLE_PARALLEL_TESTS", "false")
 	}
 
+	// Set environment variable to enable plan-only mode in the test contexts
+	if planOnly {
+		os.Setenv("TERRATEST_PLAN_ONLY", "true")
+	}
+
+	// Set environment variable to rewrite the snapshots compared by the snapshot assertions
+	if updateSnapshots {
+		os.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "true")
+	}
+
+	// Set environment variable to record the vars and outputs used by the tests, replacing earlier records
+	if recordCoverage {
+		recordsDir := filepath.Join(absPath, coverageRecordsDir)
+		if err := os.RemoveAll(recordsDir); err != nil {
+			logger.Fatal("Error removing earlier coverage records: %v", err)
+		}
+		os.Setenv("TERRATEST_COVERAGE_DIR", recordsDir)
+	}
+
+	// Set environment variable to restrict the examples run by the common tests to the selection
+	if selected != nil {
+		os.Setenv("TERRATEST_EXAMPLES", strings.Join(selected, ","))
+	}
+
+	// Set environment variable to select the CLI binary used by the test contexts
+	if binary != "" {
+		os.Setenv("TERRATEST_BINARY", binary)
+	}
+
+	// Set environment variables to select the lifecycle stages run by the test contexts
+	if len(skipStages) > 0 {
+		os.Setenv("TERRATEST_SKIP_STAGES", strings.Join(skipStages, ","))
+	}
+	if len(onlyStages) > 0 {
+		os.Setenv("TERRATEST_ONLY_STAGES", strings.Join(onlyStages, ","))
+	}
+
+	if len(versions) > 0 {
+		runVersionMatrix(absPath, plan, flags, versions, binaries)
+		return
+	}
+
+	report, err := plan.run(absPath, flags, nil, os.Stdout)
+	logger.Info("Test results:\n%s", report)
+	saveResults(absPath, report)
+	writeReportJSON(report)
+	writeJUnit(report)
+	if err != nil {
+		logger.Error("Tests failed: %v", err)
+		os.Exit(1)
+	}
+	if report.Failed() {
+		logger.Error("Tests failed")
+		os.Exit(1)
+	}
+
+	logger.Info("All tests passed! 🎉")
+}
+
+// runGoTest runs go test with -json in the module root, writes the test output to stdout as it arrives
+// and returns the aggregated results. env is added to the environment of the tftest process
+func runGoTest(absPath string, args []string, env []string, stdout io.Writer) (*results.Report, error) {
+	collector := results.NewCollector()
+
 	cmd := exec.Command("go", args...)
 	cmd.Dir = absPath
-	cmd.Stdout = os.Stdout
+	cmd.Env = append(os.Environ(), env...)
 	cmd.Stderr = os.Stderr
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return collector.Report(), err
+	}
+	if err := cmd.Start(); err != nil {
+		return collector.Report(), err
+	}
+
+	streamErr := results.Stream(pipe, stdout, collector)
+	if err := cmd.Wait(); err != nil {
+		return collector.Report(), err
+	}
+	return collector.Report(), streamErr
+}
 
-	err = cmd.Run()
+// writeReportJSON writes the results to the --report-json file, if set
+func writeReportJSON(report *results.Report) {
+	if reportJSON == "" {
+		return
+	}
+	content, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		logger.Error("Tests failed: %v", err)
+		logger.Fatal("Error encoding test results: %v", err)
+	}
+	if err := os.WriteFile(reportJSON, content, 0644); err != nil {
+		logger.Fatal("Error writing test results: %v", err)
+	}
+	logger.Info("Test results written to %s", reportJSON)
+}
+
+// saveResults saves the results of the run to the module root for tftest report
+// Failures to save are only logged, as they do not affect the test results
+func saveResults(absPath string, report *results.Report) {
+	path := filepath.Join(absPath, lastResultsFile)
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = os.WriteFile(path, content, 0644)
+	}
+	if err != nil {
+		logger.Warn("Could not save the test results for tftest report: %v", err)
+	}
+}
+
+// writeJUnit writes the results to the --junit file, if set
+func writeJUnit(report *results.Report) {
+	if junitReport == "" {
+		return
+	}
+	content, err := report.JUnit()
+	if err != nil {
+		logger.Fatal("Error encoding JUnit report: %v", err)
+	}
+	if err := os.WriteFile(junitReport, content, 0644); err != nil {
+		logger.Fatal("Error writing JUnit report: %v", err)
+	}
+	logger.Info("JUnit report written to %s", junitReport)
+}
+
+// resolveTerraformVersions returns the versions of a version matrix run from --terraform-versions,
+// --terraform-versions-file or the versions file in the module root, in that order of precedence
+func resolveTerraformVersions(absPath string) []string {
+	if len(tfVersions) > 0 {
+		return testctx.ParseVersions(strings.Join(tfVersions, ","))
+	}
+
+	path := tfVersionsFile
+	if path == "" {
+		path = filepath.Join(absPath, testctx.VersionsFile)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	versions, err := testctx.ReadVersionsFile(path)
+	if err != nil {
+		logger.Fatal("Error reading Terraform versions from %s: %v", path, err)
+	}
+	return versions
+}
+
+// resolveVersionBinaries looks up the binary of each version in the cache directory
+func resolveVersionBinaries(versions []string) map[string]string {
+	cacheDir := tfCacheDir
+	if cacheDir == "" {
+		cacheDir = testctx.VersionCacheDir()
+	}
+
+	binaries := make(map[string]string)
+	for _, version := range versions {
+		path, err := testctx.FindCachedBinary(cacheDir, testctx.BinaryTerraform, version)
+		if err != nil {
+			logger.Fatal("Error resolving Terraform binary: %v", err)
+		}
+		binaries[version] = path
+	}
+	return binaries
+}
+
+// runVersionMatrix runs the tests once per Terraform version and prints a version × example summary
+func runVersionMatrix(absPath string, plan testPlan, flags []string, versions []string, binaries map[string]string) {
+	versionMatrix := matrix.New(versions)
+	var examples []*results.Example
+	failed := false
+
+	for _, version := range versions {
+		logger.Info("Running tests with Terraform %s (%s)", version, binaries[version])
+
+		var output bytes.Buffer
+		report, err := plan.run(absPath, flags, []string{
+			"TERRATEST_BINARY=" + binaries[version],
+			"TERRATEST_TERRAFORM_VERSION=" + version,
+		}, io.MultiWriter(os.Stdout, &output))
+		if err != nil {
+			logger.Error("Tests failed with Terraform %s: %v", version, err)
+			failed = true
+		} else if report.Failed() {
+			logger.Error("Tests failed with Terraform %s", version)
+			failed = true
+		}
+		if err := versionMatrix.RecordOutput(version, &output); err != nil {
+			logger.Warn("Could not read the test results of Terraform %s: %v", version, err)
+		}
+		for _, example := range report.Examples {
+			example.TerraformVersion = version
+			examples = append(examples, example)
+		}
+	}
+
+	report := results.NewReport(examples)
+	logger.Info("Test results:\n%s", report)
+	logger.Info("Terraform version matrix:\n%s", versionMatrix)
+	saveResults(absPath, report)
+	writeReportJSON(report)
+	writeJUnit(report)
+
+	if failed || versionMatrix.Failed() {
+		logger.Error("Tests failed for at least one Terraform version")
 		os.Exit(1)
 	}
 
-	logger.Info("All tests passed! 🎉")
+	logger.Info("All tests passed for every Terraform version! 🎉")
+}
+
+// readManifests reads the tftest.yaml manifests of the examples
+func readManifests(absPath string, names []string) map[string]manifest.Manifest {
+	manifests := make(map[string]manifest.Manifest, len(names))
+	for _, name := range names {
+		m, err := manifest.Load(filepath.Join(absPath, "examples", name))
+		if err != nil {
+			logger.Fatal("Error reading manifest of example %s: %v", name, err)
+		}
+		manifests[name] = m
+	}
+	return manifests
+}
+
+// logManifests logs the examples skipped by their tftest.yaml manifest and returns the go test timeout needed
+// by the timeouts the manifests declare, counting go test's default of 10m for examples without one
+// It returns zero if no manifest declares a timeout
+func logManifests(names []string, manifests map[string]manifest.Manifest) time.Duration {
+	var total time.Duration
+	declared := false
+	for _, name := range names {
+		m := manifests[name]
+		if m.Skip.Reason != "" {
+			logger.Info("Example %s is skipped: %s", name, m.Skip.Reason)
+		}
+		if m.Skip.PlanOnly != "" && planOnly {
+			logger.Info("Example %s is skipped in plan-only mode: %s", name, m.Skip.PlanOnly)
+		}
+		if m.Timeout > 0 {
+			declared = true
+			total += m.Timeout
+		} else {
+			total += defaultTestTimeout
+		}
+	}
+	if !declared {
+		return 0
+	}
+	return total
+}
+
+// testPlan holds the test packages of a run, in stages that run one after the other
+type testPlan struct {
+	stages [][]string
+	// examples maps the test packages of single examples to their example
+	examples map[string]string
+	// dependencies maps the examples to the examples they depend on
+	dependencies map[string][]string
+}
+
+// planTests plans the test packages of the examples in stages following the depends_on of their manifests,
+// so an example's test package runs after the test packages of its dependencies. withShared adds the test
+// packages that do not belong to a single example, such as tests/common, to the last stage
+// Without dependencies between the test packages of the examples, testPaths run in a single stage
+func planTests(absPath string, testPaths, names []string, manifests map[string]manifest.Manifest, withShared bool) testPlan {
+	dependencies := map[string][]string{}
+	for _, name := range names {
+		dependencies[name] = manifests[name].DependsOn
+	}
+	exampleStages, err := manifest.Stages(names, dependencies)
+	if err != nil {
+		logger.Fatal("Error ordering examples: %v", err)
+	}
+
+	plan := testPlan{examples: map[string]string{}, dependencies: dependencies}
+	for _, stage := range exampleStages {
+		var packages []string
+		for _, name := range stage {
+			for _, pkg := range examplePackages(absPath, []string{name}) {
+				plan.examples[pkg] = name
+				packages = append(packages, pkg)
+			}
+		}
+		if len(packages) > 0 {
+			plan.stages = append(plan.stages, packages)
+		}
+	}
+
+	if len(plan.stages) <= 1 {
+		return testPlan{stages: [][]string{testPaths}}
+	}
+	if withShared {
+		last := len(plan.stages) - 1
+		plan.stages[last] = append(plan.stages[last], sharedPackages(absPath)...)
+	}
+	return plan
+}
+
+// run runs go test on the stages of the plan, one after the other, and returns their combined results
+// The test package of an example whose dependency did not pass is skipped instead of run
+func (p testPlan) run(absPath string, flags []string, env []string, stdout io.Writer) (*results.Report, error) {
+	if len(p.stages) == 1 {
+		return runGoTest(absPath, goTestArgs(p.stages[0], flags), env, stdout)
+	}
+
+	hasPackage := map[string]bool{}
+	for _, name := range p.examples {
+		hasPackage[name] = true
+	}
+	passed := map[string]bool{}
+	var examples []*results.Example
+	var runErr error
+	for _, stage := range p.stages {
+		var packages []string
+		for _, pkg := range stage {
+			name, ok := p.examples[pkg]
+			if !ok {
+				packages = append(packages, pkg)
+				continue
+			}
+			if dependency := p.failedDependency(name, hasPackage, passed); dependency != "" {
+				logger.Warn("Skipping example %s: dependency %s did not pass", name, dependency)
+				examples = append(examples, &results.Example{
+					Name:    name,
+					Package: pkg,
+					Status:  results.StatusSkip,
+					Tests:   []*results.Test{},
+					Output:  fmt.Sprintf("Skipped: dependency %s did not pass\n", dependency),
+				})
+				continue
+			}
+			packages = append(packages, pkg)
+		}
+		if len(packages) == 0 {
+			continue
+		}
+
+		report, err := runGoTest(absPath, goTestArgs(packages, flags), env, stdout)
+		if err != nil && runErr == nil {
+			runErr = err
+		}
+		for _, example := range report.Examples {
+			examples = append(examples, example)
+			if example.Status == results.StatusPass {
+				passed[example.Name] = true
+			}
+		}
+	}
+	return results.NewReport(examples), runErr
+}
+
+// failedDependency returns a dependency of an example whose test package did not pass, or "" if there is none
+// Dependencies without a test package of their own are run by the shared test packages and are not waited for
+func (p testPlan) failedDependency(name string, hasPackage, passed map[string]bool) string {
+	for _, dependency := range p.dependencies[name] {
+		if hasPackage[dependency] && !passed[dependency] {
+			return dependency
+		}
+	}
+	return ""
+}
+
+// goTestArgs returns the arguments of go test for the packages
+func goTestArgs(packages, flags []string) []string {
+	return append(append([]string{"test"}, packages...), flags...)
+}
+
+// addedDependencies returns the selected examples that were added as dependencies of the included examples
+func addedDependencies(selected, included []string) []string {
+	if len(included) == 0 {
+		return nil
+	}
+	isIncluded := map[string]bool{}
+	for _, name := range included {
+		isIncluded[name] = true
+	}
+	var added []string
+	for _, name := range selected {
+		if !isIncluded[name] {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// examplePackages returns the test packages of the selected examples that have a test directory
+func examplePackages(absPath string, selected []string) []string {
+	var packages []string
+	for _, name := range selected {
+		if _, err := os.Stat(filepath.Join(absPath, "tests", name)); err == nil {
+			packages = append(packages, fmt.Sprintf("./tests/%s/...", name))
+		}
+	}
+	return packages
+}
+
+// sharedPackages returns the test packages that do not belong to a single example, such as tests/common
+// They run the selected examples only, as TERRATEST_EXAMPLES restricts the examples the framework discovers
+func sharedPackages(absPath string) []string {
+	names, err := exampleNames(absPath)
+	if err != nil {
+		logger.Fatal("Error reading examples: %v", err)
+	}
+	isExample := map[string]bool{}
+	for _, name := range names {
+		isExample[name] = true
+	}
+
+	entries, err := os.ReadDir(filepath.Join(absPath, "tests"))
+	if err != nil {
+		logger.Fatal("Error reading tests directory: %v", err)
+	}
+	var packages []string
+	for _, entry := range entries {
+		if entry.IsDir() && !isExample[entry.Name()] {
+			packages = append(packages, fmt.Sprintf("./tests/%s/...", entry.Name()))
+		}
+	}
+	return packages
 }
 
 // verifyDirectoryStructure checks if the directory structure is as expected
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/cmd/tftest/logger"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Upgrade-check command flags
+	upgradeModuleRoot  string
+	upgradeExamplePath string
+	upgradeFrom        string
+	upgradeTo          string
+	upgradeAllow       []string
+	upgradeBinary      string
+)
+
+// upgradeCheckCmd represents the upgrade-check command
+var upgradeCheckCmd = &cobra.Command{
+	Use:   "upgrade-check",
+	Short: "Check that upgrading the module does not replace or destroy resources",
+	Long: `Check that upgrading a Terraform module between two versions is safe for existing users.
+
+The example is applied against the module source at --from, then the module source is swapped to --to
+and a plan is run. The check fails if the plan replaces or destroys any resource that is not allowlisted.
+
+A version is either a git ref of the repository containing the module (checked out into a temporary
+worktree) or a local path to a checkout of the module, which must be absolute or start with ./ or ../ so it is
+not mistaken for a ref. When --to is empty the current working tree is used.
+
+Examples:
+  tftest upgrade-check --example-path basic --from v1.2.0                # Upgrade from v1.2.0 to the working tree
+  tftest upgrade-check --example-path basic --from v1.2.0 --to v2.0.0    # Upgrade between two tags
+  tftest upgrade-check --example-path basic --from ../module-v1          # Upgrade from a local checkout
+  tftest upgrade-check --example-path basic --from v1.2.0 --allow 'module.main.random_id.*'  # Allow a replacement`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUpgradeCheck()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeCheckCmd)
+
+	// Add flags to upgrade-check command
+	upgradeCheckCmd.Flags().StringVar(&upgradeModuleRoot, "module-root", ".", "Path to the root of the Terraform module")
+	upgradeCheckCmd.Flags().StringVar(&upgradeExamplePath, "example-path", "", "Example to apply for the upgrade check (required)")
+	upgradeCheckCmd.Flags().StringVar(&upgradeFrom, "from", "", "Git ref or local path (absolute, or starting with ./ or ../) of the module version to upgrade from (required)")
+	upgradeCheckCmd.Flags().StringVar(&upgradeTo, "to", "", "Git ref or local path (absolute, or starting with ./ or ../) of the module version to upgrade to (default: working tree)")
+	upgradeCheckCmd.Flags().StringVar(&upgradeBinary, "binary", "", "CLI to run the example with: terraform, tofu or a path to a binary (default: terraform)")
+	upgradeCheckCmd.Flags().StringSliceVar(&upgradeAllow, "allow", nil, "Resource address globs that may be replaced or destroyed")
+}
+
+// runUpgradeCheck executes the upgrade check based on the provided flags
+func runUpgradeCheck() {
+	if upgradeExamplePath == "" || upgradeFrom == "" {
+		logger.Fatal("Both --example-path and --from are required")
+	}
+
+	// Get absolute path to module root
+	absPath, err := filepath.Abs(upgradeModuleRoot)
+	if err != nil {
+		logger.Fatal("Error resolving path: %v", err)
+	}
+
+	exampleDir := filepath.Join(absPath, "examples", upgradeExamplePath)
+	if _, err := os.Stat(exampleDir); os.IsNotExist(err) {
+		logger.Fatal("Example directory not found: %s", exampleDir)
+	}
+
+	to := upgradeTo
+	if to == "" {
+		to = "working tree"
+	}
+	logger.Info("Checking upgrade of example %s from %s to %s", upgradeExamplePath, upgradeFrom, to)
+
+	t := &cliT{name: "upgrade-check/" + upgradeExamplePath}
+	config := testctx.TestConfig{
+		Name:             upgradeExamplePath,
+		UpgradeAllowlist: upgradeAllow,
+		Binary:           upgradeBinary,
+	}
+
+	result, err := testctx.RunUpgradeCheckE(t, exampleDir, upgradeFrom, upgradeTo, config)
+	result.Cleanup(t)
+	if err != nil {
+		logger.Fatal("Upgrade check failed: %v", err)
+	}
+
+	if len(result.Allowed) > 0 {
+		logger.Info("Allowed destructive changes: %s", testctx.PlanDiff{Resources: result.Allowed})
+	}
+	if len(result.Destructive) > 0 {
+		logger.Error("Upgrade would replace or destroy resources: %s", testctx.PlanDiff{Resources: result.Destructive})
+		os.Exit(1)
+	}
+	if t.failed {
+		logger.Error("Upgrade check passed but cleanup reported errors")
+		os.Exit(1)
+	}
+
+	logger.Info("Upgrade check passed, no resources are replaced or destroyed 🎉")
+}
+
+// cliT adapts the CLI to the testing interface expected by terratest
+type cliT struct {
+	name   string
+	failed bool
+}
+
+func (c *cliT) Fail() {
+	c.failed = true
+}
+
+func (c *cliT) FailNow() {
+	logger.Fatal("%s failed", c.name)
+}
+
+func (c *cliT) Fatal(args ...interface{}) {
+	logger.Fatal("%s", fmt.Sprint(args...))
+}
+
+func (c *cliT) Fatalf(format string, args ...interface{}) {
+	logger.Fatal(format, args...)
+}
+
+func (c *cliT) Error(args ...interface{}) {
+	c.failed = true
+	logger.Error("%s", fmt.Sprint(args...))
+}
+
+func (c *cliT) Errorf(format string, args ...interface{}) {
+	c.failed = true
+	logger.Error(format, args...)
+}
+
+func (c *cliT) Name() string {
+	return c.name
+}
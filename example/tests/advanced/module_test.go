@@ -8,7 +8,6 @@ import (
 
 	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
 	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
-	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,28 +17,36 @@ func TestAdvancedOutput(t *testing.T) {
 	// Run the example without overriding variables - use values from terraform.tfvars
 	ctx := testctx.RunSingleExample(t, "../../examples", "advanced", testctx.TestConfig{
 		Name: "advanced",
+		// The time_static resource records when the example was applied
+		SnapshotIgnore: []string{"module.example.time_static.*"},
 	})
 
 	// Verify file exists and has correct content
-	filePath := terraform.Output(t, ctx.Terraform, "output_file_path")
+	filePath := ctx.GetOutput(t, "output_file_path")
 	assert.NotEmpty(t, filePath, "File path should not be empty")
 
 	assertions.AssertOutputContains(t, ctx, "output_content", "advanced")
 	assertions.AssertOutputContains(t, ctx, "output_content", "true")
 
-	// Get the json_data output directly - Terraform already returns this as a structured map
-	jsonData := terraform.OutputForKeys(t, ctx.Terraform, []string{"json_data"})
-	data := jsonData["json_data"].(map[string]interface{})
+	// Decode the structured json_data output into a typed struct
+	var data struct {
+		Message string `json:"message"`
+		Enabled bool   `json:"enabled"`
+	}
+	ctx.DecodeOutput(t, "json_data", &data)
 
 	// Verify specific fields
-	assert.Equal(t, "advanced", data["message"], "JSON message should match expected value")
-	assert.Equal(t, true, data["enabled"], "JSON enabled flag should match expected value")
+	assert.Equal(t, "advanced", data.Message, "JSON message should match expected value")
+	assert.Equal(t, true, data.Enabled, "JSON enabled flag should match expected value")
+
+	// Compare the outputs and resources with the snapshot in __snapshots__/advanced.json (or advanced.plan.json in plan-only mode)
+	assertions.AssertMatchesSnapshot(t, ctx, "advanced")
 }
 
 // AssertJSONStructure is a custom assertion that checks if the JSON content has the expected structure
 func AssertJSONStructure(t *testing.T, ctx testctx.TestContext, requiredKeys []string) {
 	// Get the file content from the output
-	content := terraform.Output(t, ctx.Terraform, "output_content")
+	content := ctx.GetOutput(t, "output_content")
 	assertions.AssertOutputNotEmpty(t, ctx, "output_content")
 
 	// Parse the JSON content
@@ -54,7 +61,7 @@ func AssertJSONStructure(t *testing.T, ctx testctx.TestContext, requiredKeys []s
 	}
 
 	// Use the file path from the output to check the file
-	filePath := terraform.Output(t, ctx.Terraform, "output_file_path")
+	filePath := ctx.GetOutput(t, "output_file_path")
 	assert.NotEmpty(t, filePath, "Output file path should not be empty")
 
 	// Construct the full path
@@ -84,6 +91,11 @@ func AssertJSONStructure(t *testing.T, ctx testctx.TestContext, requiredKeys []s
 // TestAdvancedJSONStructure tests that the JSON file created by the advanced example has the expected structure
 // This demonstrates a custom assertion for JSON structure validation
 func TestAdvancedJSONStructure(t *testing.T) {
+	// Plan-only mode does not create the file
+	if testctx.PlanOnlyEnabled() {
+		t.Skip("Skipping in plan-only mode: the file is only created by apply")
+	}
+
 	// Run the example
 	ctx := testctx.RunSingleExample(t, "../../examples", "advanced", testctx.TestConfig{
 		Name: "advanced-json-test",
@@ -127,8 +139,8 @@ func TestCollectionAssertions(t *testing.T) {
 	})
 
 	// IMPORTANT: Get all outputs we need immediately after apply
-	jsonData := terraform.OutputMap(t, ctx.Terraform, "json_data")
-	content := terraform.Output(t, ctx.Terraform, "output_content")
+	jsonData := ctx.GetOutputMap(t, "json_data")
+	content := ctx.GetOutput(t, "output_content")
 
 	// Extract regions list from jsonData
 	var jsonDataParsed map[string]interface{}
@@ -168,6 +180,11 @@ func TestCollectionAssertions(t *testing.T) {
 // with specific required fields and structure
 // This is a unique test specific to the advanced example
 func TestAdvancedJSONFormat(t *testing.T) {
+	// Plan-only mode does not create the file
+	if testctx.PlanOnlyEnabled() {
+		t.Skip("Skipping in plan-only mode: the file is only created by apply")
+	}
+
 	// Create a unique temporary directory for this test
 	tempDir, err := os.MkdirTemp("", "test-advanced-json-format-*")
 	assert.NoError(t, err, "Failed to create temp directory")
@@ -202,8 +219,8 @@ func TestAdvancedJSONFormat(t *testing.T) {
 
 	// IMPORTANT: Store ALL outputs we need BEFORE any assertions or idempotency tests
 	// This prevents issues with outputs not being available later
-	content := terraform.Output(t, ctx.Terraform, "output_content")
-	filePath := terraform.Output(t, ctx.Terraform, "output_file_path")
+	content := ctx.GetOutput(t, "output_content")
+	filePath := ctx.GetOutput(t, "output_file_path")
 
 	// Read the file content immediately after apply
 	fileContent, err := os.ReadFile(outputFilename)
@@ -5,8 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
 	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
-	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,20 +16,25 @@ func TestBasicOutput(t *testing.T) {
 	// Use the values from terraform.tfvars instead of overriding them
 	ctx := testctx.RunSingleExample(t, "../../examples", "basic", testctx.TestConfig{
 		Name: "basic",
+		// The time_static resource records when the example was applied
+		SnapshotIgnore: []string{"module.example.time_static.*"},
 	})
 
 	// Verify file exists and has correct content
-	filePath := terraform.Output(t, ctx.Terraform, "output_file_path")
+	filePath := ctx.GetOutput(t, "output_file_path")
 	assert.NotEmpty(t, filePath, "File path should not be empty")
 
-	content := terraform.Output(t, ctx.Terraform, "output_content")
+	content := ctx.GetOutput(t, "output_content")
 	assert.Equal(t, "hello from basic", content, "File content should match expected value")
+
+	// Compare the outputs and resources with the snapshot in __snapshots__/basic.json (or basic.plan.json in plan-only mode)
+	assertions.AssertMatchesSnapshot(t, ctx, "basic")
 }
 
 // AssertFilePermissions is a custom assertion that checks if the file has the expected permissions
 func AssertFilePermissions(t *testing.T, ctx testctx.TestContext, expectedPerm os.FileMode) {
 	// Get the file path from the output
-	filePath := terraform.Output(t, ctx.Terraform, "output_file_path")
+	filePath := ctx.GetOutput(t, "output_file_path")
 	assert.NotEmpty(t, filePath, "File path should not be empty")
 
 	// Use the correct path by prepending the working directory
@@ -47,6 +52,11 @@ func AssertFilePermissions(t *testing.T, ctx testctx.TestContext, expectedPerm o
 // TestBasicFilePermissions tests that the file created by the basic example has the expected permissions
 // This demonstrates a custom assertion for file permissions
 func TestBasicFilePermissions(t *testing.T) {
+	// Plan-only mode does not create the file
+	if testctx.PlanOnlyEnabled() {
+		t.Skip("Skipping in plan-only mode: the file is only created by apply")
+	}
+
 	// Run the example
 	ctx := testctx.RunSingleExample(t, "../../examples", "basic", testctx.TestConfig{
 		Name: "basic-permissions-test",
@@ -66,7 +76,7 @@ func TestBasicContentFormat(t *testing.T) {
 	})
 
 	// Get the output content
-	content := terraform.Output(t, ctx.Terraform, "output_content")
+	content := ctx.GetOutput(t, "output_content")
 
 	// Verify that the content is plain text (not JSON)
 	assert.Equal(t, "hello from basic", content, "Basic example should output plain text")
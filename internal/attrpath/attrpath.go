@@ -0,0 +1,40 @@
+package attrpath
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Split splits a dotted attribute path (e.g. "versioning.0.enabled") into its segments
+func Split(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+// Lookup resolves a dotted attribute path against a decoded JSON value
+// Map keys are matched by name and list elements by their numeric index
+// Returns false if any segment of the path cannot be resolved
+func Lookup(value interface{}, path string) (interface{}, bool) {
+	current := value
+	for _, segment := range Split(path) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, exists := node[segment]
+			if !exists {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
@@ -0,0 +1,351 @@
+// Package coverage reports which variables of a module the examples and tests set
+// and which outputs the tests read
+package coverage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/tfconfig"
+)
+
+// Record is an entry of a coverage log: the vars a test passed to an example or the outputs it read
+type Record struct {
+	// Example is the absolute path of the example
+	Example string   `json:"example"`
+	Vars    []string `json:"vars,omitempty"`
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// writeMu serializes appends to the coverage log of this process
+var writeMu sync.Mutex
+
+// Append adds a record to the coverage log of the current process in dir
+// Each process writes its own file, so packages tested in parallel never interleave their records
+func Append(dir string, record Record) error {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	content, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("coverage-%d.jsonl", os.Getpid()))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(content, '\n'))
+	return err
+}
+
+// ReadRecords reads the coverage logs in dir
+func ReadRecords(dir string) ([]Record, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "coverage-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var records []Record
+	for _, path := range files {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			var record Record
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+			}
+			records = append(records, record)
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// Item is a variable or output of the module and the examples that cover it
+type Item struct {
+	Name      string   `json:"name"`
+	CoveredBy []string `json:"covered_by"`
+}
+
+// Report lists the variables set and the outputs read through the examples of a module
+type Report struct {
+	Module            string   `json:"module"`
+	Variables         []Item   `json:"variables"`
+	Outputs           []Item   `json:"outputs"`
+	UntestedVariables []string `json:"untested_variables"`
+	UnassertedOutputs []string `json:"unasserted_outputs"`
+}
+
+// Analyze builds the coverage report of the module in moduleRoot from its examples and the coverage records
+// A variable of the module is covered by an example whose module block sets it to an expression without variables,
+// or to an example variable set in terraform.tfvars, a *.auto.tfvars file, the tftest.yaml manifest or a
+// TestConfig.ExtraVars recorded in a test.
+// An output of the module is covered by an example output that references it and was read by a test
+func Analyze(moduleRoot string, records []Record) (*Report, error) {
+	absRoot, err := filepath.Abs(moduleRoot)
+	if err != nil {
+		return nil, err
+	}
+	module, err := tfconfig.LoadModule(absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := newCoverage(variableNames(module))
+	outputs := newCoverage(outputNames(module))
+
+	// Tests may also run against the module root directly
+	rootVars, rootOutputs := recorded(records, absRoot)
+	for name := range rootVars {
+		variables.add(name, ".")
+	}
+	for name := range rootOutputs {
+		outputs.add(name, ".")
+	}
+
+	exampleDirs, err := filepath.Glob(filepath.Join(absRoot, "examples", "*"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(exampleDirs)
+	for _, dir := range exampleDirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := analyzeExample(absRoot, dir, records, variables, outputs); err != nil {
+			return nil, fmt.Errorf("example %s: %w", filepath.Base(dir), err)
+		}
+	}
+
+	report := &Report{Module: absRoot, Variables: variables.items(), Outputs: outputs.items()}
+	report.UntestedVariables = uncovered(report.Variables)
+	report.UnassertedOutputs = uncovered(report.Outputs)
+	return report, nil
+}
+
+// analyzeExample adds the variables and outputs of the module covered by an example
+func analyzeExample(absRoot, dir string, records []Record, variables, outputs *coverageSet) error {
+	example, err := tfconfig.LoadModule(dir)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(dir)
+
+	setVars, readOutputs := recorded(records, dir)
+	varFiles, err := filepath.Glob(filepath.Join(dir, "*.auto.tfvars"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "terraform.tfvars")); err == nil {
+		varFiles = append(varFiles, filepath.Join(dir, "terraform.tfvars"))
+	}
+
+	// The vars and var files of the example's tftest.yaml are passed to every run of the example
+	m, err := manifest.Load(dir)
+	if err != nil {
+		return err
+	}
+	for varName := range m.Vars {
+		setVars[varName] = true
+	}
+	for _, file := range m.VarFiles {
+		varFiles = append(varFiles, filepath.Join(dir, file))
+	}
+
+	for _, path := range varFiles {
+		names, err := tfconfig.VarsFileNames(path)
+		if err != nil {
+			return err
+		}
+		for _, varName := range names {
+			setVars[varName] = true
+		}
+	}
+
+	// Module blocks calling the module under test
+	calls := map[string]bool{}
+	for _, call := range example.ModuleCalls {
+		if !tfconfig.IsLocalSource(call.Source) || filepath.Clean(filepath.Join(dir, call.Source)) != absRoot {
+			continue
+		}
+		calls[call.Name] = true
+
+		for argument, refs := range call.Arguments {
+			if argumentSet(refs, setVars) {
+				variables.add(argument, name)
+			}
+		}
+	}
+
+	for _, output := range example.Outputs {
+		if !readOutputs[output.Name] {
+			continue
+		}
+		for _, ref := range output.References {
+			parts := strings.Split(ref, ".")
+			if len(parts) >= 3 && parts[0] == "module" && calls[parts[1]] {
+				outputs.add(parts[2], name)
+			}
+		}
+	}
+	return nil
+}
+
+// argumentSet reports whether a module argument receives a value set by the example or test:
+// an expression without variables, or one referencing a variable that is set
+func argumentSet(refs []string, setVars map[string]bool) bool {
+	hasVar := false
+	for _, ref := range refs {
+		if !strings.HasPrefix(ref, "var.") {
+			continue
+		}
+		hasVar = true
+		if setVars[strings.TrimPrefix(ref, "var.")] {
+			return true
+		}
+	}
+	return !hasVar
+}
+
+// recorded returns the vars and outputs recorded for an example
+func recorded(records []Record, exampleDir string) (map[string]bool, map[string]bool) {
+	vars := map[string]bool{}
+	outputs := map[string]bool{}
+	for _, record := range records {
+		if filepath.Clean(record.Example) != exampleDir {
+			continue
+		}
+		for _, name := range record.Vars {
+			vars[name] = true
+		}
+		for _, name := range record.Outputs {
+			outputs[name] = true
+		}
+	}
+	return vars, outputs
+}
+
+// String renders the report as a table of variables and outputs followed by the untested ones
+func (r *Report) String() string {
+	var b strings.Builder
+	writeSection(&b, "VARIABLE", r.Variables)
+	b.WriteString("\n")
+	writeSection(&b, "OUTPUT", r.Outputs)
+
+	fmt.Fprintf(&b, "\nVariables: %d/%d tested", len(r.Variables)-len(r.UntestedVariables), len(r.Variables))
+	if len(r.UntestedVariables) > 0 {
+		fmt.Fprintf(&b, ", untested: %s", strings.Join(r.UntestedVariables, ", "))
+	}
+	fmt.Fprintf(&b, "\nOutputs: %d/%d asserted", len(r.Outputs)-len(r.UnassertedOutputs), len(r.Outputs))
+	if len(r.UnassertedOutputs) > 0 {
+		fmt.Fprintf(&b, ", unasserted: %s", strings.Join(r.UnassertedOutputs, ", "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// writeSection writes the items with the examples covering them as an aligned table
+func writeSection(b *strings.Builder, header string, items []Item) {
+	w := tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tCOVERED BY\n", header)
+	for _, item := range items {
+		coveredBy := "-"
+		if len(item.CoveredBy) > 0 {
+			coveredBy = strings.Join(item.CoveredBy, ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\n", item.Name, coveredBy)
+	}
+	w.Flush()
+}
+
+// coverageSet collects the examples covering each name, keeping the declaration order of the names
+type coverageSet struct {
+	names     []string
+	coveredBy map[string]map[string]bool
+}
+
+// newCoverage creates a coverage set for the given names
+func newCoverage(names []string) *coverageSet {
+	set := &coverageSet{names: names, coveredBy: map[string]map[string]bool{}}
+	for _, name := range names {
+		set.coveredBy[name] = map[string]bool{}
+	}
+	return set
+}
+
+// add marks a name as covered by an example; names not declared by the module are ignored
+func (s *coverageSet) add(name, example string) {
+	if examples, ok := s.coveredBy[name]; ok {
+		examples[example] = true
+	}
+}
+
+// items returns the names with the sorted examples covering them
+func (s *coverageSet) items() []Item {
+	items := make([]Item, 0, len(s.names))
+	for _, name := range s.names {
+		coveredBy := make([]string, 0, len(s.coveredBy[name]))
+		for example := range s.coveredBy[name] {
+			coveredBy = append(coveredBy, example)
+		}
+		sort.Strings(coveredBy)
+		items = append(items, Item{Name: name, CoveredBy: coveredBy})
+	}
+	return items
+}
+
+// uncovered returns the names of the items no example covers
+func uncovered(items []Item) []string {
+	names := []string{}
+	for _, item := range items {
+		if len(item.CoveredBy) == 0 {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
+
+// variableNames returns the names of the variables of a module in declaration order
+func variableNames(module *tfconfig.Module) []string {
+	names := make([]string, 0, len(module.Variables))
+	for _, variable := range module.Variables {
+		names = append(names, variable.Name)
+	}
+	return names
+}
+
+// outputNames returns the names of the outputs of a module in declaration order
+func outputNames(module *tfconfig.Module) []string {
+	names := make([]string, 0, len(module.Outputs))
+	for _, output := range module.Outputs {
+		names = append(names, output.Name)
+	}
+	return names
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
 	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
 )
 
@@ -13,9 +14,14 @@ type Example struct {
 	Name   string
 	Path   string
 	Config testctx.TestConfig
+	// Manifest holds the settings of the example's optional tftest.yaml, which are applied to Config
+	Manifest testctx.Manifest
 }
 
 // FindAllExamples discovers all examples in the examples directory
+// Examples not selected by tftest run (see testctx.ExampleSelected) are left out
+// The tftest.yaml manifest of each example is loaded, failing the test if it is invalid, and every example
+// is returned after the examples it depends on
 func FindAllExamples(t *testing.T, moduleRootPath string) []Example {
 	examplesPath := filepath.Join(moduleRootPath, "examples")
 	entries, err := os.ReadDir(examplesPath)
@@ -23,22 +29,42 @@ func FindAllExamples(t *testing.T, moduleRootPath string) []Example {
 		t.Fatalf("Failed to read examples directory: %v", err)
 	}
 
-	var examples []Example
+	var names []string
+	found := make(map[string]Example)
+	dependencies := make(map[string][]string)
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		if !entry.IsDir() || !testctx.ExampleSelected(entry.Name()) {
 			continue
 		}
 
-		examples = append(examples, Example{
+		path := filepath.Join(examplesPath, entry.Name())
+		m, err := testctx.LoadManifest(path)
+		if err != nil {
+			t.Fatalf("Failed to load the manifest of %s: %v", entry.Name(), err)
+		}
+
+		names = append(names, entry.Name())
+		dependencies[entry.Name()] = m.DependsOn
+		found[entry.Name()] = Example{
 			Name: entry.Name(),
-			Path: filepath.Join(examplesPath, entry.Name()),
-			Config: testctx.TestConfig{
+			Path: path,
+			Config: testctx.ApplyManifest(testctx.TestConfig{
 				Name:      entry.Name(),
 				ExtraVars: map[string]interface{}{},
-			},
-		})
+			}, m),
+			Manifest: m,
+		}
 	}
 
+	names, err = manifest.Order(names, dependencies)
+	if err != nil {
+		t.Fatalf("Failed to order examples: %v", err)
+	}
+
+	var examples []Example
+	for _, name := range names {
+		examples = append(examples, found[name])
+	}
 	return examples
 }
 
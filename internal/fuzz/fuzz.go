@@ -0,0 +1,347 @@
+// Package fuzz generates boundary and random inputs for Terraform variables from their type constraints
+// and validation blocks
+package fuzz
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/tfconfig"
+)
+
+// Default generation settings
+const (
+	DefaultRandomCases = 3
+	DefaultSeed        = 1
+	DefaultLargeSize   = 1000
+)
+
+// Options controls the generated inputs
+type Options struct {
+	// RandomCases is the number of random inputs per variable (default DefaultRandomCases), a negative value disables them
+	RandomCases int
+	// Seed seeds the random inputs so runs are reproducible (default DefaultSeed)
+	Seed int64
+	// LargeSize is the length of oversized strings, lists and maps (default DefaultLargeSize)
+	LargeSize int
+}
+
+// Case is a generated input for a single variable
+type Case struct {
+	// Variable is the name of the variable the input is generated for
+	Variable string
+	// Name describes the input, e.g. "empty-string" or "random-2"
+	Name string
+	// Value is the input as a JSON-encodable value, nil for null
+	Value interface{}
+}
+
+// ID returns the variable and case name, e.g. "bucket_name/empty-string"
+func (c Case) ID() string {
+	return c.Variable + "/" + c.Name
+}
+
+// withDefaults fills in the default settings
+func (o Options) withDefaults() Options {
+	if o.RandomCases == 0 {
+		o.RandomCases = DefaultRandomCases
+	}
+	if o.Seed == 0 {
+		o.Seed = DefaultSeed
+	}
+	if o.LargeSize == 0 {
+		o.LargeSize = DefaultLargeSize
+	}
+	return o
+}
+
+// Cases generates the inputs for a variable: null, values of the wrong type, boundary values of its type,
+// values around the literals of its validation conditions and random values
+func Cases(variable tfconfig.Variable, options Options) []Case {
+	options = options.withDefaults()
+	g := &generator{options: options, rand: rand.New(rand.NewSource(options.Seed + int64(len(variable.Name))))}
+
+	cases := []Case{{Name: "null", Value: nil}}
+	cases = append(cases, g.wrongType(variable.Type)...)
+	cases = append(cases, g.boundary(variable.Type)...)
+	cases = append(cases, g.validationBoundaries(variable)...)
+	for i := 1; i <= options.RandomCases; i++ {
+		cases = append(cases, Case{Name: fmt.Sprintf("random-%d", i), Value: g.random(variable.Type, 0)})
+	}
+
+	// Drop duplicates, e.g. a validation literal equal to a boundary value
+	seen := map[string]bool{}
+	unique := cases[:0]
+	for _, c := range cases {
+		key := fmt.Sprintf("%#v", c.Value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		c.Variable = variable.Name
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// Placeholder returns a simple valid value of a type, used for required variables that are not being fuzzed
+func Placeholder(typ cty.Type) interface{} {
+	switch {
+	case typ == cty.String || typ == cty.DynamicPseudoType:
+		return "fuzz"
+	case typ == cty.Number:
+		return 1
+	case typ == cty.Bool:
+		return true
+	case typ.IsListType() || typ.IsSetType() || typ.IsTupleType():
+		return []interface{}{}
+	case typ.IsObjectType():
+		object := map[string]interface{}{}
+		for name, attrType := range typ.AttributeTypes() {
+			if !typ.AttributeOptional(name) {
+				object[name] = Placeholder(attrType)
+			}
+		}
+		return object
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// generator creates values with a seeded random source
+type generator struct {
+	options Options
+	rand    *rand.Rand
+}
+
+// wrongType returns values that cannot be converted to the type
+// Terraform converts numbers and bools to strings and back, so only structurally different values are used
+func (g *generator) wrongType(typ cty.Type) []Case {
+	switch {
+	case typ == cty.DynamicPseudoType:
+		return nil
+	case typ.IsPrimitiveType():
+		cases := []Case{
+			{Name: "wrong-type-list", Value: []interface{}{"a"}},
+			{Name: "wrong-type-object", Value: map[string]interface{}{"key": "value"}},
+		}
+		if typ == cty.Number {
+			cases = append(cases, Case{Name: "wrong-type-string", Value: "not-a-number"})
+		}
+		if typ == cty.Bool {
+			cases = append(cases, Case{Name: "wrong-type-string", Value: "maybe"})
+		}
+		return cases
+	case typ.IsListType() || typ.IsSetType() || typ.IsTupleType():
+		return []Case{
+			{Name: "wrong-type-string", Value: "not-a-list"},
+			{Name: "wrong-type-object", Value: map[string]interface{}{"key": "value"}},
+		}
+	default:
+		return []Case{
+			{Name: "wrong-type-string", Value: "not-a-map"},
+			{Name: "wrong-type-list", Value: []interface{}{"a"}},
+		}
+	}
+}
+
+// boundary returns the edge cases of a type: empty, oversized and unusual values
+func (g *generator) boundary(typ cty.Type) []Case {
+	large := g.options.LargeSize
+	switch {
+	case typ == cty.String:
+		return []Case{
+			{Name: "empty-string", Value: ""},
+			{Name: "whitespace", Value: "  \t\n"},
+			{Name: "oversized-string", Value: strings.Repeat("a", large)},
+			{Name: "unicode", Value: "ünïcødé-✓-名前"},
+			{Name: "special-characters", Value: `"quoted" ${var.x} %{if} \ / * ? [ ]`},
+		}
+	case typ == cty.Number:
+		return []Case{
+			{Name: "zero", Value: 0},
+			{Name: "negative", Value: -1},
+			{Name: "fraction", Value: 0.5},
+			{Name: "huge", Value: 1e308},
+		}
+	case typ == cty.Bool:
+		return []Case{{Name: "true", Value: true}, {Name: "false", Value: false}}
+	case typ.IsListType() || typ.IsSetType():
+		element := typ.ElementType()
+		oversized := make([]interface{}, large)
+		for i := range oversized {
+			oversized[i] = g.distinct(element, i)
+		}
+		return []Case{
+			{Name: "empty-list", Value: []interface{}{}},
+			{Name: "oversized-list", Value: oversized},
+			{Name: "null-element", Value: []interface{}{nil}},
+		}
+	case typ.IsMapType():
+		element := typ.ElementType()
+		oversized := make(map[string]interface{}, large)
+		for i := 0; i < large; i++ {
+			oversized[fmt.Sprintf("key-%d", i)] = g.distinct(element, i)
+		}
+		return []Case{
+			{Name: "empty-map", Value: map[string]interface{}{}},
+			{Name: "oversized-map", Value: oversized},
+			{Name: "empty-key", Value: map[string]interface{}{"": Placeholder(element)}},
+		}
+	case typ.IsObjectType():
+		nulls := map[string]interface{}{}
+		for name := range typ.AttributeTypes() {
+			nulls[name] = nil
+		}
+		return []Case{
+			{Name: "empty-object", Value: map[string]interface{}{}},
+			{Name: "null-attributes", Value: nulls},
+		}
+	case typ == cty.DynamicPseudoType:
+		return []Case{
+			{Name: "empty-string", Value: ""},
+			{Name: "empty-list", Value: []interface{}{}},
+			{Name: "empty-object", Value: map[string]interface{}{}},
+		}
+	}
+	return nil
+}
+
+// validationBoundaries returns values around the literals of the validation conditions
+// Numbers are used as values and lengths on either side of the limit, strings as values and in other cases
+func (g *generator) validationBoundaries(variable tfconfig.Variable) []Case {
+	var cases []Case
+	for _, validation := range variable.Validations {
+		for _, literal := range validation.Literals {
+			switch literal.Type() {
+			case cty.Number:
+				n, _ := literal.AsBigFloat().Int64()
+				if n < 0 || n > int64(g.options.LargeSize) {
+					continue
+				}
+				for _, size := range []int64{n - 1, n, n + 1} {
+					if size < 0 {
+						continue
+					}
+					if value, ok := g.sized(variable.Type, int(size)); ok {
+						cases = append(cases, Case{Name: fmt.Sprintf("validation-%d", size), Value: value})
+					}
+				}
+			case cty.String:
+				if variable.Type != cty.String && variable.Type != cty.DynamicPseudoType {
+					continue
+				}
+				s := literal.AsString()
+				cases = append(cases,
+					Case{Name: fmt.Sprintf("validation-%q", s), Value: s},
+					Case{Name: fmt.Sprintf("validation-%q-upper", s), Value: strings.ToUpper(s)},
+					Case{Name: fmt.Sprintf("validation-%q-padded", s), Value: " " + s + " "},
+				)
+			}
+		}
+	}
+	return cases
+}
+
+// sized returns a value of the type with the given size: the number itself, or a string, list or map of that length
+func (g *generator) sized(typ cty.Type, size int) (interface{}, bool) {
+	switch {
+	case typ == cty.Number:
+		return size, true
+	case typ == cty.String:
+		return strings.Repeat("a", size), true
+	case typ.IsListType() || typ.IsSetType():
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = g.distinct(typ.ElementType(), i)
+		}
+		return list, true
+	case typ.IsMapType():
+		m := make(map[string]interface{}, size)
+		for i := 0; i < size; i++ {
+			m[fmt.Sprintf("key-%d", i)] = g.distinct(typ.ElementType(), i)
+		}
+		return m, true
+	}
+	return nil, false
+}
+
+// distinct returns the i-th value of a type, distinct for strings and numbers so sets keep every element
+func (g *generator) distinct(typ cty.Type, i int) interface{} {
+	switch typ {
+	case cty.String, cty.DynamicPseudoType:
+		return fmt.Sprintf("item-%d", i)
+	case cty.Number:
+		return i
+	}
+	return Placeholder(typ)
+}
+
+// randomChars are the characters random strings are built from
+var randomChars = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@ äø✓名")
+
+// random returns a random value of the type; nested collections are kept small
+func (g *generator) random(typ cty.Type, depth int) interface{} {
+	switch {
+	case typ == cty.String || typ == cty.DynamicPseudoType:
+		runes := make([]rune, g.rand.Intn(64))
+		for i := range runes {
+			runes[i] = randomChars[g.rand.Intn(len(randomChars))]
+		}
+		return string(runes)
+	case typ == cty.Number:
+		if g.rand.Intn(2) == 0 {
+			return g.rand.Intn(2000001) - 1000000
+		}
+		return (g.rand.Float64() - 0.5) * 1e6
+	case typ == cty.Bool:
+		return g.rand.Intn(2) == 0
+	case typ.IsListType() || typ.IsSetType():
+		list := make([]interface{}, g.rand.Intn(g.maxLength(depth)))
+		for i := range list {
+			list[i] = g.random(typ.ElementType(), depth+1)
+		}
+		return list
+	case typ.IsTupleType():
+		elements := typ.TupleElementTypes()
+		list := make([]interface{}, len(elements))
+		for i, element := range elements {
+			list[i] = g.random(element, depth+1)
+		}
+		return list
+	case typ.IsMapType():
+		m := map[string]interface{}{}
+		for i := g.rand.Intn(g.maxLength(depth)); i > 0; i-- {
+			m[g.random(cty.String, depth+1).(string)] = g.random(typ.ElementType(), depth+1)
+		}
+		return m
+	case typ.IsObjectType():
+		names := make([]string, 0, len(typ.AttributeTypes()))
+		for name := range typ.AttributeTypes() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		object := map[string]interface{}{}
+		for _, name := range names {
+			if typ.AttributeOptional(name) && g.rand.Intn(2) == 0 {
+				continue
+			}
+			object[name] = g.random(typ.AttributeType(name), depth+1)
+		}
+		return object
+	}
+	return nil
+}
+
+// maxLength returns the exclusive upper bound of random collection lengths at a nesting depth
+func (g *generator) maxLength(depth int) int {
+	if depth > 1 {
+		return 2
+	}
+	return 6
+}
@@ -0,0 +1,31 @@
+package glob
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Match reports whether value matches the glob pattern
+// '*' matches any sequence of characters (including dots and brackets) and '?' matches a single character
+// All other characters match literally, so Terraform addresses such as aws_s3_bucket.b["logs"] need no escaping
+func Match(pattern, value string) bool {
+	return compile(pattern).MatchString(value)
+}
+
+// compile converts a glob pattern into an anchored regular expression
+func compile(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
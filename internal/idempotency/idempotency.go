@@ -4,10 +4,10 @@ import (
 	"testing"
 
 	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
-	"github.com/gruntwork-io/terratest/modules/terraform"
 )
 
 // Test runs an idempotency test on the given context
+// It uses the exit code of terraform plan -detailed-exitcode and reports the changed addresses and attributes
 // This test is run by default unless explicitly disabled via TERRATEST_IDEMPOTENCY=false
 func Test(t *testing.T, ctx testctx.TestContext) bool {
 	if !testctx.IdempotencyEnabled() {
@@ -15,16 +15,19 @@ func Test(t *testing.T, ctx testctx.TestContext) bool {
 		return true
 	}
 
-	t.Logf("Running idempotency test for %s", ctx.Config.Name)
-	planResult := terraform.Plan(t, ctx.Terraform)
+	return testctx.CheckIdempotency(t, ctx)
+}
 
-	if planResult != "" {
-		t.Errorf("Idempotency test failed for %s: Terraform plan would make changes: %s", ctx.Config.Name, planResult)
-		return false
+// TestConvergence applies the context up to maxApplies times until a plan reports no changes
+// It logs the apply number at which the config converged and fails the test if it never did
+func TestConvergence(t *testing.T, ctx testctx.TestContext, maxApplies int) bool {
+	if !testctx.IdempotencyEnabled() {
+		t.Logf("Convergence testing disabled for %s via TERRATEST_IDEMPOTENCY=false", ctx.Config.Name)
+		return true
 	}
 
-	t.Logf("Idempotency test passed for %s", ctx.Config.Name)
-	return true
+	ctx.Config.MaxApplies = maxApplies
+	return testctx.CheckConvergence(t, ctx)
 }
 
 // TestAll runs idempotency tests on all contexts
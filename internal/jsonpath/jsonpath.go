@@ -0,0 +1,203 @@
+// Package jsonpath resolves simple JSONPath expressions against decoded JSON values
+// Supported are the root ($), child names (.name or ['name']) and list indexes ([0], or [-1] for the last element)
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Segment is a single step of a path: a child name or a list index
+type Segment struct {
+	Name    string
+	Index   int
+	IsIndex bool
+}
+
+// String returns the segment in path notation
+func (s Segment) String() string {
+	if s.IsIndex {
+		return fmt.Sprintf("[%d]", s.Index)
+	}
+	if isIdentifier(s.Name) {
+		return "." + s.Name
+	}
+	return fmt.Sprintf("[%q]", s.Name)
+}
+
+// ResolveError reports a path that could not be resolved, with the deepest part of the value that was resolved
+type ResolveError struct {
+	// Path is the full path that was resolved
+	Path string
+	// Resolved is the part of the path that could be resolved, e.g. "$.regions"
+	Resolved string
+	// Subtree is the value at Resolved
+	Subtree interface{}
+	// Reason describes why the next segment could not be resolved
+	Reason string
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("path %s not found: %s at %s, which is %s", e.Path, e.Reason, e.Resolved, Format(e.Subtree))
+}
+
+// Parse parses a path such as "$.regions[0]", "$['tag name'].value" or "regions[0]" (the leading $ is optional)
+func Parse(path string) ([]Segment, error) {
+	rest := strings.TrimSpace(path)
+	rest = strings.TrimPrefix(rest, "$")
+
+	var segments []Segment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid path %q: empty name", path)
+			}
+			segments = append(segments, Segment{Name: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			if len(rest) > 1 && (rest[1] == '\'' || rest[1] == '"') {
+				// Quoted names may contain any character but the closing quote followed by ]
+				closing := strings.Index(rest[2:], string(rest[1])+"]")
+				if closing < 0 {
+					return nil, fmt.Errorf("invalid path %q: unterminated name", path)
+				}
+				segments = append(segments, Segment{Name: rest[2 : 2+closing]})
+				rest = rest[2+closing+2:]
+				continue
+			}
+
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q: missing ]", path)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid path %q: %q is not an index", path, rest[1:end])
+			}
+			segments = append(segments, Segment{Index: index, IsIndex: true})
+			rest = rest[end+1:]
+		default:
+			if len(segments) > 0 || strings.HasPrefix(strings.TrimSpace(path), "$") {
+				return nil, fmt.Errorf("invalid path %q: unexpected %q", path, rest[0])
+			}
+			// A path without a leading $ starts with a name
+			rest = "." + rest
+		}
+	}
+	return segments, nil
+}
+
+// Resolve returns the value at path within value, or a *ResolveError if the path does not exist
+// Strings holding JSON objects or lists are decoded when the path continues into them,
+// so JSON-encoded outputs (e.g. produced by jsonencode) can be addressed like structured ones
+func Resolve(value interface{}, path string) (interface{}, error) {
+	segments, err := Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := value
+	resolved := "$"
+	for _, segment := range segments {
+		current = decodeJSONString(current)
+
+		fail := func(reason string) error {
+			return &ResolveError{Path: path, Resolved: resolved, Subtree: current, Reason: reason}
+		}
+
+		switch node := current.(type) {
+		case map[string]interface{}:
+			if segment.IsIndex {
+				return nil, fail(fmt.Sprintf("cannot index an object with %s", segment))
+			}
+			next, exists := node[segment.Name]
+			if !exists {
+				return nil, fail(fmt.Sprintf("no key %q", segment.Name))
+			}
+			current = next
+		case []interface{}:
+			if !segment.IsIndex {
+				return nil, fail(fmt.Sprintf("cannot look up %q in a list", segment.Name))
+			}
+			index := segment.Index
+			if index < 0 {
+				index += len(node)
+			}
+			if index < 0 || index >= len(node) {
+				return nil, fail(fmt.Sprintf("index %d out of range (length %d)", segment.Index, len(node)))
+			}
+			current = node[index]
+		default:
+			return nil, fail(fmt.Sprintf("cannot resolve %s in a %s", segment, typeName(current)))
+		}
+		resolved += segment.String()
+	}
+	return current, nil
+}
+
+// Format renders a value as compact JSON for messages
+func Format(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(content)
+}
+
+// decodeJSONString decodes a string holding a JSON object or list and returns any other value unchanged
+func decodeJSONString(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return value
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(trimmed), &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// typeName names the JSON type of a decoded value
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64, json.Number:
+		return "number"
+	case bool:
+		return "bool"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "list"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// isIdentifier reports whether a name can be written in dot notation
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
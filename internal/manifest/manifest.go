@@ -0,0 +1,289 @@
+// Package manifest reads the optional tftest.yaml metadata file of an example and selects examples by it
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the metadata file in the directory of an example
+const FileName = "tftest.yaml"
+
+// Manifest is the metadata of an example declared in examples/<name>/tftest.yaml
+type Manifest struct {
+	// Tags label the example for tftest run --tags, e.g. slow, smoke or needs-creds
+	Tags []string `yaml:"tags"`
+	// Timeout is the time the example may take from init to the last assertion, e.g. 30m
+	Timeout time.Duration `yaml:"timeout"`
+	// Vars are passed to Terraform as -var, in addition to the ExtraVars of the test config
+	Vars map[string]interface{} `yaml:"vars"`
+	// VarFiles are passed to Terraform as -var-file, relative to the example directory
+	VarFiles []string `yaml:"var_files"`
+	// Env holds the environment variables Terraform runs with
+	Env map[string]string `yaml:"env"`
+	// Skip holds the reasons to skip the example
+	Skip Skip `yaml:"skip"`
+	// Idempotency configures the idempotency check run after apply
+	Idempotency Idempotency `yaml:"idempotency"`
+	// ExpectedOutputs are compared with the outputs of the example after apply
+	ExpectedOutputs map[string]interface{} `yaml:"expected_outputs"`
+	// DependsOn lists the examples that run before this example; it is skipped if one of them fails
+	DependsOn []string `yaml:"depends_on"`
+}
+
+// Skip holds the reasons to skip an example, which are reported as the skip message of its test
+type Skip struct {
+	// Reason skips the example in every run
+	Reason string `yaml:"reason"`
+	// PlanOnly skips the example in plan-only mode, e.g. when its plan depends on resources created by apply
+	PlanOnly string `yaml:"plan_only"`
+}
+
+// Idempotency configures the idempotency check of an example
+type Idempotency struct {
+	// Enabled turns the check off when false; it runs by default
+	Enabled *bool `yaml:"enabled"`
+	// MaxApplies is the number of applies allowed for the example to converge
+	MaxApplies int `yaml:"max_applies"`
+	// Ignore lists expected perpetual diffs
+	Ignore []IgnoreRule `yaml:"ignore"`
+}
+
+// IgnoreRule describes an expected perpetual diff (see testctx.IdempotencyIgnoreRule)
+type IgnoreRule struct {
+	Address    string   `yaml:"address"`
+	Attributes []string `yaml:"attributes"`
+}
+
+// Load reads the manifest of an example directory
+// An example without a manifest returns an empty manifest. Unknown fields and invalid values are reported as errors
+func Load(exampleDir string) (Manifest, error) {
+	var m Manifest
+	path := filepath.Join(exampleDir, FileName)
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && err != io.EOF {
+		return m, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := m.validate(exampleDir); err != nil {
+		return m, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// validate checks the values that cannot be checked by their type
+func (m Manifest) validate(exampleDir string) error {
+	if m.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if m.Idempotency.MaxApplies < 0 {
+		return fmt.Errorf("idempotency.max_applies must not be negative")
+	}
+	for i, rule := range m.Idempotency.Ignore {
+		if rule.Address == "" {
+			return fmt.Errorf("idempotency.ignore[%d].address is required", i)
+		}
+	}
+	for _, file := range m.VarFiles {
+		if _, err := os.Stat(filepath.Join(exampleDir, file)); err != nil {
+			return fmt.Errorf("var file %s not found in %s", file, exampleDir)
+		}
+	}
+	for _, dependency := range m.DependsOn {
+		if dependency == filepath.Base(exampleDir) {
+			return fmt.Errorf("the example cannot depend on itself")
+		}
+	}
+	return nil
+}
+
+// HasAnyTag reports whether the manifest declares at least one of the tags
+func (m Manifest) HasAnyTag(tags ...string) bool {
+	for _, tag := range tags {
+		for _, declared := range m.Tags {
+			if declared == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Filter selects examples by name and tag
+type Filter struct {
+	// Include selects only these examples; all examples are selected when empty
+	Include []string
+	// Exclude removes these examples from the selection
+	Exclude []string
+	// Tags selects only the examples declaring at least one of these tags
+	Tags []string
+}
+
+// Empty reports whether the filter selects every example
+func (f Filter) Empty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.Tags) == 0
+}
+
+// Select returns the names of the examples in the examples directory of a module matched by the filter,
+// together with the examples they depend on unless those are excluded
+// The names are sorted, with every example after its dependencies (see Order)
+// Included or excluded examples that do not exist are reported as an error
+func Select(moduleRoot string, filter Filter) ([]string, error) {
+	examplesDir := filepath.Join(moduleRoot, "examples")
+	entries, err := os.ReadDir(examplesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := map[string]bool{}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			exists[entry.Name()] = true
+			names = append(names, entry.Name())
+		}
+	}
+	for _, name := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if !exists[name] {
+			return nil, fmt.Errorf("example %s not found in %s", name, examplesDir)
+		}
+	}
+
+	manifests := map[string]Manifest{}
+	dependencies := map[string][]string{}
+	for _, name := range names {
+		m, err := Load(filepath.Join(examplesDir, name))
+		if err != nil {
+			return nil, err
+		}
+		for _, dependency := range m.DependsOn {
+			if !exists[dependency] {
+				return nil, fmt.Errorf("example %s depends on %s, which is not found in %s", name, dependency, examplesDir)
+			}
+		}
+		manifests[name] = m
+		dependencies[name] = m.DependsOn
+	}
+
+	included := toSet(filter.Include)
+	excluded := toSet(filter.Exclude)
+	selected := map[string]bool{}
+	var add func(name string)
+	add = func(name string) {
+		if selected[name] || excluded[name] {
+			return
+		}
+		selected[name] = true
+		for _, dependency := range dependencies[name] {
+			add(dependency)
+		}
+	}
+	for _, name := range names {
+		if len(included) > 0 && !included[name] {
+			continue
+		}
+		if len(filter.Tags) > 0 && !manifests[name].HasAnyTag(filter.Tags...) {
+			continue
+		}
+		add(name)
+	}
+
+	ordered := []string{}
+	for _, name := range names {
+		if selected[name] {
+			ordered = append(ordered, name)
+		}
+	}
+	return Order(ordered, dependencies)
+}
+
+// Order sorts names so that every name comes after its dependencies, keeping the order of the names otherwise
+// Dependencies that are not in names are ignored. A dependency cycle is reported as an error
+func Order(names []string, dependencies map[string][]string) ([]string, error) {
+	present := toSet(names)
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	ordered := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+		state[name] = visiting
+		for _, dependency := range dependencies[name] {
+			if !present[dependency] {
+				continue
+			}
+			if err := visit(dependency, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		ordered = append(ordered, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// Stages groups names into stages that can run together, every name in a later stage than its dependencies
+// Names keep their order within a stage. Dependencies that are not in names are ignored
+func Stages(names []string, dependencies map[string][]string) ([][]string, error) {
+	ordered, err := Order(names, dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	present := toSet(names)
+	level := map[string]int{}
+	var stages [][]string
+	for _, name := range ordered {
+		for _, dependency := range dependencies[name] {
+			if present[dependency] && level[dependency] >= level[name] {
+				level[name] = level[dependency] + 1
+			}
+		}
+		if level[name] == len(stages) {
+			stages = append(stages, nil)
+		}
+		stages[level[name]] = append(stages[level[name]], name)
+	}
+	return stages, nil
+}
+
+// toSet returns the names as a set
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
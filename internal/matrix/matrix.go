@@ -0,0 +1,142 @@
+// Package matrix collects the results of running the example tests once per Terraform version
+// and renders them as a version × example pass/fail table
+package matrix
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Status is the result of the tests of an example for one version
+type Status string
+
+const (
+	// StatusPass means all tests of the example passed
+	StatusPass Status = "PASS"
+	// StatusFail means at least one test of the example failed
+	StatusFail Status = "FAIL"
+	// StatusNone means the example was not run for the version
+	StatusNone Status = "-"
+)
+
+// packageResultRegex matches the per-package result lines printed by go test
+// e.g. "ok  	github.com/org/module/tests/basic	12.3s" or "FAIL	github.com/org/module/tests/basic [build failed]"
+var packageResultRegex = regexp.MustCompile(`^(ok|FAIL)\s+(\S+)`)
+
+// Matrix records the status of each example for each version
+type Matrix struct {
+	// Versions lists the versions in the order they were run
+	Versions []string
+	results  map[string]map[string]Status
+}
+
+// New creates an empty matrix for the given versions
+func New(versions []string) *Matrix {
+	return &Matrix{Versions: versions, results: map[string]map[string]Status{}}
+}
+
+// Record sets the status of an example for a version
+// A failure is never overwritten, so an example with several test packages fails if any of them fails
+func (m *Matrix) Record(version, example string, status Status) {
+	if m.results[example] == nil {
+		m.results[example] = map[string]Status{}
+	}
+	if m.results[example][version] == StatusFail {
+		return
+	}
+	m.results[example][version] = status
+}
+
+// RecordOutput records the package results in the output of a go test run for a version
+func (m *Matrix) RecordOutput(version string, output io.Reader) error {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		match := packageResultRegex.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		status := StatusPass
+		if match[1] == "FAIL" {
+			status = StatusFail
+		}
+		m.Record(version, ExampleName(match[2]), status)
+	}
+	return scanner.Err()
+}
+
+// Status returns the status of an example for a version
+func (m *Matrix) Status(version, example string) Status {
+	if status, ok := m.results[example][version]; ok {
+		return status
+	}
+	return StatusNone
+}
+
+// Examples returns the recorded examples in alphabetical order
+func (m *Matrix) Examples() []string {
+	examples := make([]string, 0, len(m.results))
+	for example := range m.results {
+		examples = append(examples, example)
+	}
+	sort.Strings(examples)
+	return examples
+}
+
+// Failed reports whether any example failed for any version
+func (m *Matrix) Failed() bool {
+	for _, versions := range m.results {
+		for _, status := range versions {
+			if status == StatusFail {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// String renders the matrix as a table with one row per example and one column per version
+func (m *Matrix) String() string {
+	header := append([]string{"EXAMPLE"}, m.Versions...)
+	rows := [][]string{header}
+	for _, example := range m.Examples() {
+		row := []string{example}
+		for _, version := range m.Versions {
+			row = append(row, string(m.Status(version, example)))
+		}
+		rows = append(rows, row)
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	lines := make([]string, len(rows))
+	for r, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		lines[r] = strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ExampleName returns the example a test package belongs to, which is the directory below tests/
+// e.g. github.com/org/module/tests/basic returns basic. Packages outside tests/ are returned unchanged
+func ExampleName(pkg string) string {
+	if i := strings.LastIndex(pkg, "/tests/"); i >= 0 {
+		return strings.SplitN(pkg[i+len("/tests/"):], "/", 2)[0]
+	}
+	return pkg
+}
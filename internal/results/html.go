@@ -0,0 +1,176 @@
+package results
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// reportTemplate is the HTML report, with its styles and scripts inlined so it can be viewed offline
+//
+//go:embed report.html.tmpl
+var reportTemplate string
+
+// HTMLOptions configures the HTML report
+type HTMLOptions struct {
+	// Title is the title of the report (default: tftest results)
+	Title string
+	// Generated is the time shown as the generation time of the report; it is left out when zero
+	Generated time.Time
+}
+
+// htmlReport is the data rendered by the HTML report template
+type htmlReport struct {
+	Title     string
+	Generated string
+	Summary   Summary
+	Duration  string
+	Examples  []htmlExample
+}
+
+// htmlExample is a collapsible section of an example in the HTML report
+type htmlExample struct {
+	Label    string
+	Package  string
+	Status   Status
+	Duration string
+	Passed   int
+	Failed   int
+	Skipped  int
+	Tests    []htmlTest
+	Output   string
+}
+
+// htmlTest is a collapsible row of a test in the HTML report
+type htmlTest struct {
+	Name       string
+	Status     Status
+	Duration   string
+	Open       bool
+	Failure    string
+	PlanDiffs  []string
+	Phases     []htmlPhase
+	Benchmarks []htmlBenchmark
+	Output     string
+}
+
+// htmlPhase is a Terraform phase of a test, with its share of the time of all phases of the test
+type htmlPhase struct {
+	Name     string
+	Duration string
+	Percent  string
+	Color    int
+}
+
+// htmlBenchmark is a benchmark of a test in the HTML report
+type htmlBenchmark struct {
+	Name     string
+	Duration string
+	Success  bool
+	Error    string
+}
+
+// phaseColors gives each lifecycle stage the same color in every test; other phases use the last color
+var phaseColors = map[string]int{"init": 0, "plan": 0, "apply": 1, "idempotency": 2, "validate": 3, "destroy": 4}
+
+// HTML writes the report as a single static HTML page with a collapsible section per example and test,
+// filtering by status, and the failures, plan diffs, Terraform phase timings and benchmarks of each test
+func (r *Report) HTML(w io.Writer, options HTMLOptions) error {
+	tmpl, err := template.New("report").Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+
+	view := htmlReport{
+		Title:    options.Title,
+		Summary:  r.Summary,
+		Duration: formatDuration(r.Summary.Duration),
+	}
+	if view.Title == "" {
+		view.Title = "tftest results"
+	}
+	if !options.Generated.IsZero() {
+		view.Generated = options.Generated.Format(time.RFC1123)
+	}
+
+	for _, example := range r.Examples {
+		section := htmlExample{
+			Label:    example.label(),
+			Package:  example.Package,
+			Status:   example.Status,
+			Duration: formatDuration(example.Duration),
+			Output:   example.Output,
+		}
+		for _, test := range example.Tests {
+			switch test.Status {
+			case StatusPass:
+				section.Passed++
+			case StatusFail:
+				section.Failed++
+			case StatusSkip:
+				section.Skipped++
+			}
+			section.Tests = append(section.Tests, newHTMLTest(test))
+		}
+		view.Examples = append(view.Examples, section)
+	}
+
+	return tmpl.Execute(w, view)
+}
+
+// newHTMLTest converts a test into its row of the HTML report
+// Failed tests are expanded, except for parent tests whose failure is that of a subtest
+func newHTMLTest(test *Test) htmlTest {
+	row := htmlTest{
+		Name:      test.Name,
+		Status:    test.Status,
+		Duration:  formatDuration(test.Duration),
+		PlanDiffs: test.PlanDiffs,
+		Output:    test.Output,
+	}
+
+	if test.Status == StatusFail {
+		_, failure := failureOutput(test.Output)
+		if failure != test.Output {
+			row.Failure = failure
+			row.Open = true
+		}
+	}
+
+	var total float64
+	for _, phase := range test.Phases {
+		total += phase.Duration
+	}
+	for _, phase := range test.Phases {
+		percent := 0.0
+		if total > 0 {
+			percent = phase.Duration / total * 100
+		}
+		row.Phases = append(row.Phases, htmlPhase{
+			Name:     phase.Name,
+			Duration: formatDuration(phase.Duration),
+			Percent:  fmt.Sprintf("%.2f", percent),
+			Color:    phaseColor(phase.Name),
+		})
+	}
+
+	for _, benchmark := range test.Benchmarks {
+		row.Benchmarks = append(row.Benchmarks, htmlBenchmark{
+			Name:     benchmark.Name,
+			Duration: formatDuration(benchmark.Duration),
+			Success:  benchmark.Success,
+			Error:    benchmark.Error,
+		})
+	}
+	return row
+}
+
+// phaseColor returns the index of the color of a phase in the report styles
+func phaseColor(name string) int {
+	if color, ok := phaseColors[name]; ok {
+		return color
+	}
+	return 5
+}
@@ -0,0 +1,170 @@
+package results
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// junitSuites is the root element of a JUnit XML report
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+// junitSuite is the testsuite element of an example
+type junitSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties"`
+	Cases      []junitCase      `xml:"testcase"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+// junitCase is the testcase element of a test or subtest
+type junitCase struct {
+	Name       string           `xml:"name,attr"`
+	Classname  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties"`
+	Failure    *junitMessage    `xml:"failure"`
+	Skipped    *junitMessage    `xml:"skipped"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+// junitProperties is the properties element of a testsuite or testcase
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+// junitProperty is a name and value pair of a testsuite or testcase
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// junitMessage is the failure or skipped element of a testcase
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders the report as JUnit XML, with a testsuite per example and a testcase per test and subtest
+// The output of a test, including the Terraform output logged by terratest, is written to its system-out,
+// the log messages describing its failure to its failure element, and its phase durations to its properties
+func (r *Report) JUnit() ([]byte, error) {
+	root := junitSuites{
+		Name:     "tftest",
+		Tests:    r.Summary.Tests,
+		Failures: r.Summary.Failed,
+		Skipped:  r.Summary.Skipped,
+		Time:     formatSeconds(r.Summary.Duration),
+	}
+
+	for _, example := range r.Examples {
+		suite := junitSuite{
+			Name:       example.suiteName(),
+			Time:       formatSeconds(example.Duration),
+			Properties: &junitProperties{[]junitProperty{{Name: "package", Value: example.Package}}},
+			SystemOut:  example.Output,
+		}
+		if example.TerraformVersion != "" {
+			suite.Properties.Properties = append(suite.Properties.Properties, junitProperty{Name: "terraform_version", Value: example.TerraformVersion})
+		}
+
+		for _, test := range example.Tests {
+			suite.Cases = append(suite.Cases, junitTestCase(suite.Name, test))
+		}
+		if example.Status == StatusFail && len(example.Tests) == 0 {
+			suite.Cases = append(suite.Cases, junitCase{
+				Name:      "[no tests ran]",
+				Classname: suite.Name,
+				Time:      formatSeconds(example.Duration),
+				Failure:   &junitMessage{Message: "The test package failed before running any test", Type: "failure", Text: example.Output},
+			})
+			root.Tests++
+			root.Failures++
+		}
+
+		for _, testCase := range suite.Cases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			}
+			if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		root.Suites = append(root.Suites, suite)
+	}
+
+	content, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(content, '\n')...), nil
+}
+
+// junitTestCase converts a test into a testcase of a suite
+func junitTestCase(suite string, test *Test) junitCase {
+	testCase := junitCase{
+		Name:      test.Name,
+		Classname: suite,
+		Time:      formatSeconds(test.Duration),
+		SystemOut: test.Output,
+	}
+
+	var names []string
+	durations := map[string]float64{}
+	for _, phase := range test.Phases {
+		if _, ok := durations[phase.Name]; !ok {
+			names = append(names, phase.Name)
+		}
+		durations[phase.Name] += phase.Duration
+	}
+	if len(names) > 0 {
+		testCase.Properties = &junitProperties{}
+	}
+	for _, name := range names {
+		testCase.Properties.Properties = append(testCase.Properties.Properties, junitProperty{
+			Name:  "terraform.phase." + name + ".duration",
+			Value: formatSeconds(durations[name]),
+		})
+	}
+
+	switch test.Status {
+	case StatusFail:
+		message, text := failureOutput(test.Output)
+		testCase.Failure = &junitMessage{Message: message, Type: "failure", Text: text}
+	case StatusSkip:
+		testCase.Skipped = &junitMessage{Message: skipMessage(test.Output)}
+	}
+	return testCase
+}
+
+// suiteName returns the directory of the test package of an example, e.g. tests/basic,
+// suffixed with the Terraform version in version matrix runs
+func (e *Example) suiteName() string {
+	name := e.Package
+	if i := strings.LastIndex(e.Package, "/tests/"); i >= 0 {
+		name = e.Package[i+1:]
+	}
+	if e.TerraformVersion != "" {
+		name += "@" + e.TerraformVersion
+	}
+	return name
+}
+
+// formatSeconds formats a duration in seconds for the time attributes of JUnit XML
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
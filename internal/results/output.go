@@ -0,0 +1,156 @@
+package results
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// logLine matches the lines written by t.Log and t.Error, e.g. "    basic_test.go:12: message"
+var logLine = regexp.MustCompile(`^\s*\S+\.go:\d+: ?(.*)$`)
+
+// failureText matches the log messages that describe a failure
+var failureText = regexp.MustCompile(`(?i)error|fail`)
+
+// planDiffText matches the idempotency and convergence failures that hold a plan diff (see testctx.PlanDiff)
+var planDiffText = regexp.MustCompile(`(?s)test failed for .*Terraform plan would change`)
+
+// benchmarkLine matches the log lines of benchmark.Benchmark, e.g. "Benchmark completed: apply (2.5s)"
+var benchmarkLine = regexp.MustCompile(`Benchmark (completed|failed): (.+?) \(([^()\s]+)\)(?:: (.*))?$`)
+
+// Benchmark is the result of a benchmark run by a test with the benchmark package
+type Benchmark struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration_seconds"`
+	Success  bool    `json:"success"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// logMessages splits the output of a test into its log messages, each with its indented continuation lines
+// The === and --- lines of go test and the phase lines are left out
+func logMessages(output string) []string {
+	var messages []string
+	var message []string
+	flush := func() {
+		if len(message) > 0 {
+			messages = append(messages, strings.Join(message, "\n"))
+		}
+		message = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "--- "), strings.HasPrefix(line, "=== "):
+			flush()
+		case logLine.MatchString(line):
+			flush()
+			if !strings.Contains(line, PhaseMarker) {
+				message = append(message, line)
+			}
+		case len(message) > 0 && strings.TrimSpace(line) != "":
+			message = append(message, line)
+		}
+	}
+	flush()
+	return messages
+}
+
+// failureOutput returns the first line and the text of the log messages of a test that describe its failure,
+// such as t.Errorf messages, testify assertion failures and the errors of Terraform
+func failureOutput(output string) (string, string) {
+	var failures []string
+	for _, message := range logMessages(output) {
+		if failureText.MatchString(message) {
+			failures = append(failures, message)
+		}
+	}
+	if len(failures) == 0 {
+		return "Test failed", output
+	}
+	return firstLine(failures[0]), strings.Join(failures, "\n")
+}
+
+// ParsePlanDiffs returns the plan diffs of the failed idempotency and convergence checks in the output of a test,
+// without the file and line prefix and indentation added by go test
+func ParsePlanDiffs(output string) []string {
+	var diffs []string
+	for _, message := range logMessages(output) {
+		if planDiffText.MatchString(message) {
+			diffs = append(diffs, dedent(message))
+		}
+	}
+	return diffs
+}
+
+// ParseBenchmarks returns the benchmarks logged by benchmark.Benchmark in the output of a test
+func ParseBenchmarks(output string) []Benchmark {
+	var benchmarks []Benchmark
+	for _, line := range strings.Split(output, "\n") {
+		match := benchmarkLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if match == nil {
+			continue
+		}
+		duration, err := time.ParseDuration(match[3])
+		if err != nil {
+			continue
+		}
+		benchmarks = append(benchmarks, Benchmark{
+			Name:     match[2],
+			Duration: duration.Seconds(),
+			Success:  match[1] == "completed",
+			Error:    match[4],
+		})
+	}
+	return benchmarks
+}
+
+// skipMessage returns the message of the t.Skip call of a test, or the last message it logged
+func skipMessage(output string) string {
+	var message string
+	for _, line := range strings.Split(output, "\n") {
+		if match := logLine.FindStringSubmatch(line); match != nil && !strings.Contains(line, PhaseMarker) {
+			message = strings.TrimSpace(match[1])
+		}
+	}
+	return message
+}
+
+// firstLine returns the text of the first line of a log message, truncated for the message attribute
+func firstLine(message string) string {
+	lines := strings.Split(dedent(message), "\n")
+	line := strings.TrimSpace(lines[0])
+	if line == "" {
+		// testify writes its failures on the lines following an empty log message
+		for _, next := range lines[1:] {
+			if next = strings.TrimSpace(next); next != "" {
+				line = next
+				break
+			}
+		}
+	}
+	if len(line) > 200 {
+		line = line[:200] + "..."
+	}
+	return line
+}
+
+// dedent removes the file and line prefix of a log message and the indentation go test adds to its
+// continuation lines, which is four spaces more than the indentation of the message, keeping the
+// indentation of the message itself
+func dedent(message string) string {
+	lines := strings.Split(message, "\n")
+	indent := len(lines[0]) - len(strings.TrimLeft(lines[0], " ")) + 4
+	if match := logLine.FindStringSubmatch(lines[0]); match != nil {
+		lines[0] = match[1]
+	}
+
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " ")
+		if n := len(lines[i]) - len(trimmed); n > indent {
+			lines[i] = lines[i][indent:]
+		} else {
+			lines[i] = trimmed
+		}
+	}
+	return strings.Join(lines, "\n")
+}
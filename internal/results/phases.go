@@ -0,0 +1,45 @@
+package results
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PhaseMarker prefixes the test log lines holding the duration of a Terraform phase, e.g.
+// "tftest-phase: apply took 12.345s"
+const PhaseMarker = "tftest-phase:"
+
+// phasePattern matches the phase log lines in the output of a test
+var phasePattern = regexp.MustCompile(regexp.QuoteMeta(PhaseMarker) + ` (\S+) took ([0-9.]+)s`)
+
+// Phase is the duration of a Terraform phase of a test, such as init, apply, idempotency or destroy
+type Phase struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// FormatPhase formats the log line of a phase read back by ParsePhases
+func FormatPhase(name string, d time.Duration) string {
+	return fmt.Sprintf("%s %s took %.3fs", PhaseMarker, name, d.Seconds())
+}
+
+// ParsePhases returns the phases logged in the output of a test, in the order they ran
+// A phase that ran more than once, such as apply in a convergence test, is returned once per run
+func ParsePhases(output string) []Phase {
+	var phases []Phase
+	for _, line := range strings.Split(output, "\n") {
+		match := phasePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		seconds, err := strconv.ParseFloat(match[2], 64)
+		if err != nil {
+			continue
+		}
+		phases = append(phases, Phase{Name: match[1], Duration: seconds})
+	}
+	return phases
+}
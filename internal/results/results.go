@@ -0,0 +1,327 @@
+// Package results aggregates the event stream of go test -json into results per example and test
+package results
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/matrix"
+)
+
+// Status is the result of an example or test
+type Status string
+
+const (
+	// StatusPass means the test passed
+	StatusPass Status = "pass"
+	// StatusFail means the test failed, or did not finish because the test binary panicked or timed out
+	StatusFail Status = "fail"
+	// StatusSkip means the test was skipped
+	StatusSkip Status = "skip"
+)
+
+// Event is an event written by go test -json (see go doc test2json)
+type Event struct {
+	Time    time.Time `json:"Time"`
+	Action  string    `json:"Action"`
+	Package string    `json:"Package"`
+	Test    string    `json:"Test"`
+	Elapsed float64   `json:"Elapsed"`
+	Output  string    `json:"Output"`
+}
+
+// Test is the result of a test function or subtest
+type Test struct {
+	// Name is the full name of the test, e.g. TestExamples/Example_basic
+	Name     string  `json:"name"`
+	Status   Status  `json:"status"`
+	Duration float64 `json:"duration_seconds"`
+	// Phases holds the durations of the Terraform phases run by the test
+	Phases []Phase `json:"phases,omitempty"`
+	// PlanDiffs holds the plan diffs of the failed idempotency and convergence checks of the test
+	PlanDiffs []string `json:"plan_diffs,omitempty"`
+	// Benchmarks holds the benchmarks run by the test with the benchmark package
+	Benchmarks []Benchmark `json:"benchmarks,omitempty"`
+	// Output holds the output of the test, including the output of its t.Log calls
+	Output string `json:"output,omitempty"`
+}
+
+// Example is the result of the test package of an example, e.g. tests/basic
+type Example struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	// TerraformVersion is the Terraform version of a version matrix run
+	TerraformVersion string  `json:"terraform_version,omitempty"`
+	Status           Status  `json:"status"`
+	Duration         float64 `json:"duration_seconds"`
+	Tests            []*Test `json:"tests"`
+	// Output holds the output of the package that belongs to no test, such as build errors
+	Output string `json:"output,omitempty"`
+}
+
+// Summary counts the tests of a report by status
+type Summary struct {
+	Examples int     `json:"examples"`
+	Tests    int     `json:"tests"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Skipped  int     `json:"skipped"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// Report holds the results of a tftest run
+type Report struct {
+	Summary  Summary    `json:"summary"`
+	Examples []*Example `json:"examples"`
+}
+
+// Collector aggregates go test -json events into a report
+type Collector struct {
+	examples map[string]*Example
+	tests    map[string]map[string]*Test
+	order    []string
+}
+
+// NewCollector creates an empty collector
+func NewCollector() *Collector {
+	return &Collector{examples: map[string]*Example{}, tests: map[string]map[string]*Test{}}
+}
+
+// Add adds an event to the results of its package and test
+func (c *Collector) Add(event Event) {
+	if event.Package == "" {
+		return
+	}
+	example := c.example(event.Package)
+
+	if event.Test == "" {
+		switch event.Action {
+		case "output":
+			example.Output += event.Output
+		case "pass", "fail", "skip":
+			example.Status = Status(event.Action)
+			example.Duration = event.Elapsed
+		}
+		return
+	}
+
+	test := c.test(example, event.Test)
+	switch event.Action {
+	case "output":
+		test.Output += event.Output
+	case "pass", "fail", "skip":
+		test.Status = Status(event.Action)
+		test.Duration = event.Elapsed
+	}
+}
+
+// Report returns the collected results with examples sorted by name
+// Tests that never finished are reported as failed
+func (c *Collector) Report() *Report {
+	examples := make([]*Example, 0, len(c.order))
+	for _, pkg := range c.order {
+		example := c.examples[pkg]
+		for _, test := range example.Tests {
+			if test.Status == "" {
+				test.Status = StatusFail
+			}
+			test.Phases = ParsePhases(test.Output)
+			test.PlanDiffs = ParsePlanDiffs(test.Output)
+			test.Benchmarks = ParseBenchmarks(test.Output)
+		}
+		if example.Status == "" {
+			example.Status = StatusFail
+		}
+		examples = append(examples, example)
+	}
+	return NewReport(examples)
+}
+
+// example returns the example of a package, creating it on its first event
+func (c *Collector) example(pkg string) *Example {
+	if example, ok := c.examples[pkg]; ok {
+		return example
+	}
+	example := &Example{Name: matrix.ExampleName(pkg), Package: pkg, Tests: []*Test{}}
+	c.examples[pkg] = example
+	c.tests[pkg] = map[string]*Test{}
+	c.order = append(c.order, pkg)
+	return example
+}
+
+// test returns a test of an example, creating it on its first event
+func (c *Collector) test(example *Example, name string) *Test {
+	if test, ok := c.tests[example.Package][name]; ok {
+		return test
+	}
+	test := &Test{Name: name}
+	c.tests[example.Package][name] = test
+	example.Tests = append(example.Tests, test)
+	return test
+}
+
+// NewReport creates a report of examples, sorted by name and Terraform version, and counts their tests
+func NewReport(examples []*Example) *Report {
+	sort.SliceStable(examples, func(i, j int) bool {
+		if examples[i].Name != examples[j].Name {
+			return examples[i].Name < examples[j].Name
+		}
+		return examples[i].TerraformVersion < examples[j].TerraformVersion
+	})
+
+	report := &Report{Examples: examples}
+	report.Summary.Examples = len(examples)
+	for _, example := range examples {
+		report.Summary.Duration += example.Duration
+		for _, test := range example.Tests {
+			report.Summary.Tests++
+			switch test.Status {
+			case StatusPass:
+				report.Summary.Passed++
+			case StatusFail:
+				report.Summary.Failed++
+			case StatusSkip:
+				report.Summary.Skipped++
+			}
+		}
+	}
+	return report
+}
+
+// Stream reads go test -json output, adds each event to the collector and writes the test output to w,
+// so the output reads like go test -v. Lines that are not JSON events, such as build errors, are written as is
+func Stream(r io.Reader, w io.Writer, collector *Collector) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var event Event
+		if len(line) == 0 || line[0] != '{' || json.Unmarshal(line, &event) != nil {
+			fmt.Fprintf(w, "%s\n", line)
+			continue
+		}
+		collector.Add(event)
+		io.WriteString(w, event.Output)
+	}
+	return scanner.Err()
+}
+
+// ReadReport reads the results written by tftest run --report-json, or the event stream of go test -json
+func ReadReport(r io.Reader) (*Report, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var report Report
+	if json.Unmarshal(content, &report) == nil && report.Examples != nil {
+		return &report, nil
+	}
+
+	collector := NewCollector()
+	if err := Stream(bytes.NewReader(content), io.Discard, collector); err != nil {
+		return nil, err
+	}
+	return collector.Report(), nil
+}
+
+// Failed reports whether any example or test failed
+func (r *Report) Failed() bool {
+	for _, example := range r.Examples {
+		if example.Status == StatusFail {
+			return true
+		}
+	}
+	return r.Summary.Failed > 0
+}
+
+// FailedTests returns the full names of the failed tests, prefixed with their example
+func (r *Report) FailedTests() []string {
+	var names []string
+	for _, example := range r.Examples {
+		for _, test := range example.Tests {
+			if test.Status == StatusFail {
+				names = append(names, example.label()+": "+test.Name)
+			}
+		}
+		if example.Status == StatusFail && len(example.Tests) == 0 {
+			names = append(names, example.label()+": no tests ran")
+		}
+	}
+	return names
+}
+
+// String renders the summary table with one row per example, followed by the failed tests
+func (r *Report) String() string {
+	rows := [][]string{{"EXAMPLE", "STATUS", "TESTS", "PASS", "FAIL", "SKIP", "DURATION"}}
+	for _, example := range r.Examples {
+		var passed, failed, skipped int
+		for _, test := range example.Tests {
+			switch test.Status {
+			case StatusPass:
+				passed++
+			case StatusFail:
+				failed++
+			case StatusSkip:
+				skipped++
+			}
+		}
+		rows = append(rows, []string{
+			example.label(), strings.ToUpper(string(example.Status)), fmt.Sprint(len(example.Tests)),
+			fmt.Sprint(passed), fmt.Sprint(failed), fmt.Sprint(skipped), formatDuration(example.Duration),
+		})
+	}
+	rows = append(rows, []string{
+		"TOTAL", "", fmt.Sprint(r.Summary.Tests), fmt.Sprint(r.Summary.Passed), fmt.Sprint(r.Summary.Failed),
+		fmt.Sprint(r.Summary.Skipped), formatDuration(r.Summary.Duration),
+	})
+
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+
+	if failed := r.FailedTests(); len(failed) > 0 {
+		lines = append(lines, "", "Failed tests:")
+		for _, name := range failed {
+			lines = append(lines, "  "+name)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// label returns the name of the example, suffixed with the Terraform version in version matrix runs
+func (e *Example) label() string {
+	if e.TerraformVersion != "" {
+		return e.Name + "@" + e.TerraformVersion
+	}
+	return e.Name
+}
+
+// formatDuration formats a duration in seconds, e.g. 12.3s or 2m5s
+func formatDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", seconds)
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,571 @@
+// Package tfconfig reads the parts of a Terraform module configuration used by the framework
+// directly from its .tf files, without running Terraform
+package tfconfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultRegistry is the registry host of provider sources without an explicit host
+const DefaultRegistry = "registry.terraform.io"
+
+// Module holds the configuration of a single Terraform module directory
+type Module struct {
+	// Dir is the directory the module was read from
+	Dir string
+	// RequiredVersions holds the required_version constraints of all terraform blocks
+	RequiredVersions []string
+	// RequiredProviders holds the required_providers entries of all terraform blocks, keyed by local name
+	RequiredProviders map[string]*ProviderRequirement
+	// ModuleCalls holds the module blocks of the module
+	ModuleCalls []ModuleCall
+	// Variables holds the variable blocks of the module in the order they are declared
+	Variables []Variable
+	// Outputs holds the output blocks of the module in the order they are declared
+	Outputs []Output
+}
+
+// ProviderRequirement is a required_providers entry
+type ProviderRequirement struct {
+	// Source is the provider source address, e.g. hashicorp/aws
+	Source string
+	// Versions holds the version constraints of the provider
+	Versions []string
+}
+
+// ModuleCall is a module block
+type ModuleCall struct {
+	Name   string
+	Source string
+	// Arguments maps each input variable set by the block to the references of its expression, e.g. "var.name"
+	Arguments map[string][]string
+	// Forwards maps each input variable set to exactly one variable of the calling module, e.g. name = var.name,
+	// to the name of that variable
+	Forwards map[string]string
+}
+
+// Variable is a variable block
+type Variable struct {
+	Name        string
+	Description string
+	// Type is the type constraint, cty.DynamicPseudoType for variables without a type or with type any
+	Type cty.Type
+	// Default is the default value, cty.NilVal for required variables
+	Default cty.Value
+	// Nullable is false if the variable sets nullable = false
+	Nullable  bool
+	Sensitive bool
+	// Validations holds the validation blocks of the variable
+	Validations []Validation
+}
+
+// Validation is a validation block of a variable
+type Validation struct {
+	// Condition is the source of the condition expression
+	Condition string
+	// ErrorMessage is the error message, or its source if it is not a constant string
+	ErrorMessage string
+	// Literals holds the constant strings and numbers of the condition, e.g. the limits of a length check
+	Literals []cty.Value
+}
+
+// Output is an output block
+type Output struct {
+	Name        string
+	Description string
+	Sensitive   bool
+	// References holds the references of the value expression, e.g. "module.example.bucket_name"
+	References []string
+}
+
+// Required reports whether the variable has no default value
+func (v Variable) Required() bool {
+	return v.Default == cty.NilVal
+}
+
+// Variable returns the variable with the given name
+func (m *Module) Variable(name string) (Variable, bool) {
+	for _, variable := range m.Variables {
+		if variable.Name == name {
+			return variable, true
+		}
+	}
+	return Variable{}, false
+}
+
+// Address returns the fully qualified provider address used by terraform version -json,
+// e.g. registry.terraform.io/hashicorp/aws for the local name aws without a source
+func (r ProviderRequirement) Address(localName string) string {
+	return ProviderAddress(localName, r.Source)
+}
+
+// ProviderAddress returns the fully qualified address of a provider from its local name and source
+func ProviderAddress(localName, source string) string {
+	if source == "" {
+		source = "hashicorp/" + localName
+	}
+	source = strings.ToLower(source)
+	if strings.Count(source, "/") == 1 {
+		return DefaultRegistry + "/" + source
+	}
+	return source
+}
+
+// IsLocalSource reports whether a module source refers to a local directory
+func IsLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// LoadModule reads the .tf files of a module directory
+func LoadModule(dir string) (*Module, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .tf files found in %s", dir)
+	}
+	sort.Strings(files)
+
+	module := &Module{Dir: dir, RequiredProviders: map[string]*ProviderRequirement{}}
+	parser := hclparse.NewParser()
+	for _, path := range files {
+		file, diags := parser.ParseHCLFile(path)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		if err := module.read(file.Body, file.Bytes); err != nil {
+			return nil, err
+		}
+	}
+	return module, nil
+}
+
+// LoadModuleTree reads a module and, recursively, all modules it calls from local directories
+// Modules from registries or remote sources are not read
+func LoadModuleTree(dir string) ([]*Module, error) {
+	var modules []*Module
+	seen := map[string]bool{}
+
+	var load func(dir string) error
+	load = func(dir string) error {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if seen[absDir] {
+			return nil
+		}
+		seen[absDir] = true
+
+		module, err := LoadModule(absDir)
+		if err != nil {
+			return err
+		}
+		modules = append(modules, module)
+
+		for _, call := range module.ModuleCalls {
+			if !IsLocalSource(call.Source) {
+				continue
+			}
+			if err := load(filepath.Join(absDir, call.Source)); err != nil {
+				return fmt.Errorf("module %s: %w", call.Name, err)
+			}
+		}
+		return nil
+	}
+
+	if err := load(dir); err != nil {
+		return nil, err
+	}
+	return modules, nil
+}
+
+// rootSchema selects the top-level blocks read by LoadModule
+var rootSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "terraform"},
+		{Type: "module", LabelNames: []string{"name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "output", LabelNames: []string{"name"}},
+	},
+}
+
+// terraformSchema selects the settings read from terraform blocks
+var terraformSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "required_version"}},
+	Blocks:     []hcl.BlockHeaderSchema{{Type: "required_providers"}},
+}
+
+// moduleSchema selects the settings read from module blocks
+var moduleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "source"}},
+}
+
+// variableSchema selects the settings read from variable blocks
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "description"},
+		{Name: "type"},
+		{Name: "default"},
+		{Name: "nullable"},
+		{Name: "sensitive"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{{Type: "validation"}},
+}
+
+// validationSchema selects the settings read from validation blocks
+var validationSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "condition"}, {Name: "error_message"}},
+}
+
+// outputSchema selects the settings read from output blocks
+var outputSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "description"}, {Name: "sensitive"}, {Name: "value"}},
+}
+
+// read adds the blocks of a file body to the module, src is the content of the file
+func (m *Module) read(body hcl.Body, src []byte) error {
+	content, _, diags := body.PartialContent(rootSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "terraform":
+			if err := m.readTerraformBlock(block.Body); err != nil {
+				return err
+			}
+		case "module":
+			call := ModuleCall{Name: block.Labels[0], Arguments: moduleArguments(block.Body), Forwards: moduleForwards(block.Body)}
+			moduleContent, _, diags := block.Body.PartialContent(moduleSchema)
+			if diags.HasErrors() {
+				return diags
+			}
+			if attr, ok := moduleContent.Attributes["source"]; ok {
+				source, err := stringValue(attr.Expr)
+				if err != nil {
+					return fmt.Errorf("module %s source: %w", call.Name, err)
+				}
+				call.Source = source
+			}
+			m.ModuleCalls = append(m.ModuleCalls, call)
+		case "variable":
+			variable, err := readVariable(block, src)
+			if err != nil {
+				return fmt.Errorf("variable %s: %w", block.Labels[0], err)
+			}
+			m.Variables = append(m.Variables, variable)
+		case "output":
+			output, err := readOutput(block)
+			if err != nil {
+				return fmt.Errorf("output %s: %w", block.Labels[0], err)
+			}
+			m.Outputs = append(m.Outputs, output)
+		}
+	}
+	return nil
+}
+
+// moduleMetaArguments are the module block arguments that are not input variables
+var moduleMetaArguments = map[string]bool{
+	"source": true, "version": true, "providers": true, "count": true, "for_each": true, "depends_on": true,
+}
+
+// moduleArguments returns the input variables set by a module block and the references of their expressions
+func moduleArguments(body hcl.Body) map[string][]string {
+	arguments := map[string][]string{}
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return arguments
+	}
+	for name, attr := range syntaxBody.Attributes {
+		if !moduleMetaArguments[name] {
+			arguments[name] = references(attr.Expr)
+		}
+	}
+	return arguments
+}
+
+// moduleForwards returns the input variables set by a module block to a variable of the calling module as is
+func moduleForwards(body hcl.Body) map[string]string {
+	forwards := map[string]string{}
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return forwards
+	}
+	for name, attr := range syntaxBody.Attributes {
+		if moduleMetaArguments[name] {
+			continue
+		}
+		expr, ok := attr.Expr.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || len(expr.Traversal) != 2 || expr.Traversal.RootName() != "var" {
+			continue
+		}
+		if step, ok := expr.Traversal[1].(hcl.TraverseAttr); ok {
+			forwards[name] = step.Name
+		}
+	}
+	return forwards
+}
+
+// references returns the references of an expression as dotted names, e.g. "var.name" or "module.example.id"
+// Each reference ends before its first index step
+func references(expr hcl.Expression) []string {
+	var refs []string
+	for _, traversal := range expr.Variables() {
+		parts := []string{traversal.RootName()}
+		for _, step := range traversal[1:] {
+			attr, ok := step.(hcl.TraverseAttr)
+			if !ok {
+				break
+			}
+			parts = append(parts, attr.Name)
+		}
+		refs = append(refs, strings.Join(parts, "."))
+	}
+	return refs
+}
+
+// readVariable reads the type, default and validations of a variable block
+func readVariable(block *hcl.Block, src []byte) (Variable, error) {
+	variable := Variable{Name: block.Labels[0], Type: cty.DynamicPseudoType, Nullable: true}
+	content, _, diags := block.Body.PartialContent(variableSchema)
+	if diags.HasErrors() {
+		return variable, diags
+	}
+
+	var err error
+	if attr, ok := content.Attributes["description"]; ok {
+		if variable.Description, err = stringValue(attr.Expr); err != nil {
+			return variable, fmt.Errorf("description: %w", err)
+		}
+	}
+	if attr, ok := content.Attributes["type"]; ok {
+		typ, _, diags := typeexpr.TypeConstraintWithDefaults(attr.Expr)
+		if diags.HasErrors() {
+			return variable, diags
+		}
+		variable.Type = typ
+	}
+	if attr, ok := content.Attributes["default"]; ok {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return variable, diags
+		}
+		variable.Default = value
+	}
+	if attr, ok := content.Attributes["nullable"]; ok {
+		if variable.Nullable, err = boolValue(attr.Expr); err != nil {
+			return variable, fmt.Errorf("nullable: %w", err)
+		}
+	}
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		if variable.Sensitive, err = boolValue(attr.Expr); err != nil {
+			return variable, fmt.Errorf("sensitive: %w", err)
+		}
+	}
+
+	for _, validationBlock := range content.Blocks {
+		validationContent, _, diags := validationBlock.Body.PartialContent(validationSchema)
+		if diags.HasErrors() {
+			return variable, diags
+		}
+
+		var validation Validation
+		if attr, ok := validationContent.Attributes["condition"]; ok {
+			validation.Condition = string(attr.Expr.Range().SliceBytes(src))
+			validation.Literals = literals(attr.Expr)
+		}
+		if attr, ok := validationContent.Attributes["error_message"]; ok {
+			message, err := stringValue(attr.Expr)
+			if err != nil {
+				message = string(attr.Expr.Range().SliceBytes(src))
+			}
+			validation.ErrorMessage = message
+		}
+		variable.Validations = append(variable.Validations, validation)
+	}
+	return variable, nil
+}
+
+// readOutput reads the description, sensitivity and value references of an output block
+func readOutput(block *hcl.Block) (Output, error) {
+	output := Output{Name: block.Labels[0]}
+	content, _, diags := block.Body.PartialContent(outputSchema)
+	if diags.HasErrors() {
+		return output, diags
+	}
+
+	var err error
+	if attr, ok := content.Attributes["description"]; ok {
+		if output.Description, err = stringValue(attr.Expr); err != nil {
+			return output, fmt.Errorf("description: %w", err)
+		}
+	}
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		if output.Sensitive, err = boolValue(attr.Expr); err != nil {
+			return output, fmt.Errorf("sensitive: %w", err)
+		}
+	}
+	if attr, ok := content.Attributes["value"]; ok {
+		output.References = references(attr.Expr)
+	}
+	return output, nil
+}
+
+// literals collects the constant strings and numbers of an expression
+func literals(expr hcl.Expression) []cty.Value {
+	syntaxExpr, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+
+	var values []cty.Value
+	hclsyntax.VisitAll(syntaxExpr, func(node hclsyntax.Node) hcl.Diagnostics {
+		literal, ok := node.(*hclsyntax.LiteralValueExpr)
+		if !ok || literal.Val.IsNull() || !literal.Val.IsKnown() {
+			return nil
+		}
+		if literal.Val.Type() == cty.String || literal.Val.Type() == cty.Number {
+			values = append(values, literal.Val)
+		}
+		return nil
+	})
+	return values
+}
+
+// VarsFileNames returns the names of the variables set in a .tfvars or .tfvars.json file
+func VarsFileNames(path string) ([]string, error) {
+	parser := hclparse.NewParser()
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = parser.ParseJSONFile(path)
+	} else {
+		file, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// readTerraformBlock reads required_version and required_providers from a terraform block
+func (m *Module) readTerraformBlock(body hcl.Body) error {
+	content, _, diags := body.PartialContent(terraformSchema)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	if attr, ok := content.Attributes["required_version"]; ok {
+		constraint, err := stringValue(attr.Expr)
+		if err != nil {
+			return fmt.Errorf("required_version: %w", err)
+		}
+		m.RequiredVersions = append(m.RequiredVersions, constraint)
+	}
+
+	for _, block := range content.Blocks {
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			return diags
+		}
+		for name, attr := range attrs {
+			requirement := m.RequiredProviders[name]
+			if requirement == nil {
+				requirement = &ProviderRequirement{}
+				m.RequiredProviders[name] = requirement
+			}
+			if err := readProviderRequirement(requirement, attr.Expr); err != nil {
+				return fmt.Errorf("required_providers %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// readProviderRequirement reads a required_providers entry, which is either a version string
+// or an object with source and version (configuration_aliases are ignored)
+func readProviderRequirement(requirement *ProviderRequirement, expr hcl.Expression) error {
+	pairs, diags := hcl.ExprMap(expr)
+	if diags.HasErrors() {
+		version, err := stringValue(expr)
+		if err != nil {
+			return err
+		}
+		requirement.Versions = append(requirement.Versions, version)
+		return nil
+	}
+
+	for _, pair := range pairs {
+		key := hcl.ExprAsKeyword(pair.Key)
+		if key == "" {
+			keyValue, err := stringValue(pair.Key)
+			if err != nil {
+				return err
+			}
+			key = keyValue
+		}
+
+		switch key {
+		case "source":
+			source, err := stringValue(pair.Value)
+			if err != nil {
+				return fmt.Errorf("source: %w", err)
+			}
+			requirement.Source = source
+		case "version":
+			version, err := stringValue(pair.Value)
+			if err != nil {
+				return fmt.Errorf("version: %w", err)
+			}
+			requirement.Versions = append(requirement.Versions, version)
+		}
+	}
+	return nil
+}
+
+// boolValue evaluates a constant bool expression
+func boolValue(expr hcl.Expression) (bool, error) {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if value.IsNull() || !value.IsKnown() || !value.Type().Equals(cty.Bool) {
+		return false, fmt.Errorf("expected a bool at %s", expr.Range())
+	}
+	return value.True(), nil
+}
+
+// stringValue evaluates a constant string expression
+func stringValue(expr hcl.Expression) (string, error) {
+	value, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if value.IsNull() || !value.IsKnown() || !value.Type().Equals(cty.String) {
+		return "", fmt.Errorf("expected a string at %s", expr.Range())
+	}
+	return value.AsString(), nil
+}
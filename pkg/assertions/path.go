@@ -0,0 +1,82 @@
+package assertions
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/jsonpath"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/stretchr/testify/assert"
+)
+
+// AssertOutputPathEquals checks if the value at a JSONPath within an output equals an expected value
+// The output may be structured (an object or list) or a JSON-encoded string, e.g. "$.regions[0]" or "$['tags'].env"
+// Numbers, maps and slices are compared by their JSON representation, so 3 equals 3.0 and []string equals []interface{}
+func AssertOutputPathEquals(t testing.TB, ctx testctx.TestContext, outputName string, path string, expectedValue interface{}) {
+	value, ok := resolveOutputPath(t, ctx, outputName, path)
+	if !ok {
+		return
+	}
+
+	expected, err := normalizeJSON(expectedValue)
+	if !assert.NoError(t, err, "Expected value for %s of output %s should be JSON encodable", path, outputName) {
+		return
+	}
+	assert.Equal(t, expected, value, "Output %s at %s should equal expected value, got %s",
+		outputName, path, jsonpath.Format(value))
+}
+
+// AssertOutputPathExists checks if a JSONPath exists within an output
+func AssertOutputPathExists(t testing.TB, ctx testctx.TestContext, outputName string, path string) {
+	resolveOutputPath(t, ctx, outputName, path)
+}
+
+// AssertOutputPathMatches checks if the value at a JSONPath within an output matches a regular expression
+// Strings are matched as is and other values by their JSON representation
+func AssertOutputPathMatches(t testing.TB, ctx testctx.TestContext, outputName string, path string, regex string) {
+	value, ok := resolveOutputPath(t, ctx, outputName, path)
+	if !ok {
+		return
+	}
+
+	text, isString := value.(string)
+	if !isString {
+		text = jsonpath.Format(value)
+	}
+	pattern, err := regexp.Compile(regex)
+	if !assert.NoError(t, err, "Regex should be valid") {
+		return
+	}
+	assert.Regexp(t, pattern, text, "Output %s at %s should match pattern %s", outputName, path, regex)
+}
+
+// resolveOutputPath resolves a JSONPath within an output, failing the test with the resolved subtree if it does not exist
+func resolveOutputPath(t testing.TB, ctx testctx.TestContext, outputName string, path string) (interface{}, bool) {
+	raw, err := ctx.OutputE(t, outputName)
+	if !assert.NoError(t, err, "Output %s should exist", outputName) {
+		return nil, false
+	}
+
+	var output interface{}
+	if !assert.NoError(t, json.Unmarshal(raw, &output), "Output %s should be valid JSON", outputName) {
+		return nil, false
+	}
+
+	value, err := jsonpath.Resolve(output, path)
+	if !assert.NoError(t, err, "Output %s should contain %s", outputName, path) {
+		return nil, false
+	}
+	return value, true
+}
+
+// normalizeJSON converts a Go value to its decoded JSON representation
+func normalizeJSON(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(content, &normalized)
+	return normalized, err
+}
@@ -0,0 +1,122 @@
+package plan
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/attrpath"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+)
+
+// LoadPlanFile parses a recorded plan JSON file (the output of terraform show -json) into a plan struct
+// This is useful for offline tests that assert on recorded plan fixtures
+func LoadPlanFile(t testing.TB, path string) *terraform.PlanStruct {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read plan file %s: %v", path, err)
+	}
+
+	planStruct, err := terraform.ParsePlanJSON(string(content))
+	if err != nil {
+		t.Fatalf("Failed to parse plan file %s: %v", path, err)
+	}
+
+	return planStruct
+}
+
+// AssertPlanCreates checks that the plan creates the resource with the given address
+func AssertPlanCreates(t testing.TB, ctx testctx.TestContext, address string) {
+	if !requirePlan(t, ctx) {
+		return
+	}
+
+	change, exists := ctx.Plan.ResourceChangesMap[address]
+	if !assert.True(t, exists, "Plan should contain a change for resource %s", address) {
+		return
+	}
+	if !assert.NotNil(t, change.Change, "Plan should contain the planned actions of resource %s", address) {
+		return
+	}
+	assert.True(t, change.Change.Actions.Create(),
+		"Resource %s should be created, but planned actions are %v", address, change.Change.Actions)
+}
+
+// AssertPlanDestroysNothing checks that the plan does not delete any resources, including replacements
+func AssertPlanDestroysNothing(t testing.TB, ctx testctx.TestContext) {
+	if !requirePlan(t, ctx) {
+		return
+	}
+
+	destroyed := changedAddresses(ctx.Plan, func(actions tfjson.Actions) bool {
+		return actions.Delete() || actions.Replace()
+	})
+	assert.Empty(t, destroyed, "Plan should not destroy any resources")
+}
+
+// AssertNoReplacements checks that the plan does not replace (destroy and re-create) any resources
+func AssertNoReplacements(t testing.TB, ctx testctx.TestContext) {
+	if !requirePlan(t, ctx) {
+		return
+	}
+
+	replaced := changedAddresses(ctx.Plan, func(actions tfjson.Actions) bool {
+		return actions.Replace()
+	})
+	assert.Empty(t, replaced, "Plan should not replace any resources")
+}
+
+// AssertPlannedAttributeEquals checks that a planned attribute of a resource equals an expected value
+// The attribute path is dotted, with list elements addressed by index (e.g. "versioning.0.enabled")
+func AssertPlannedAttributeEquals(t testing.TB, ctx testctx.TestContext, address string, path string, expectedValue interface{}) {
+	if !requirePlan(t, ctx) {
+		return
+	}
+
+	resource, exists := ctx.Plan.ResourcePlannedValuesMap[address]
+	if !assert.True(t, exists, "Plan should contain planned values for resource %s", address) {
+		return
+	}
+
+	value, found := attrpath.Lookup(resource.AttributeValues, path)
+	if !assert.True(t, found, "Resource %s should have a planned attribute %s (it may only be known after apply)", address, path) {
+		return
+	}
+	assert.EqualValues(t, expectedValue, value, "Planned attribute %s of resource %s should equal expected value", path, address)
+}
+
+// AssertPlanResourceCount checks that the number of managed resources in the planned values matches the expected count
+func AssertPlanResourceCount(t testing.TB, ctx testctx.TestContext, expectedCount int) {
+	if !requirePlan(t, ctx) {
+		return
+	}
+
+	count := 0
+	for _, resource := range ctx.Plan.ResourcePlannedValuesMap {
+		if resource.Mode == tfjson.ManagedResourceMode {
+			count++
+		}
+	}
+
+	assert.Equal(t, expectedCount, count, "Planned resource count should match expected count")
+}
+
+// requirePlan checks that the test context carries a parsed plan
+func requirePlan(t testing.TB, ctx testctx.TestContext) bool {
+	return assert.NotNil(t, ctx.Plan, "Test context should contain a plan, run the example with RunExamplePlanOnly")
+}
+
+// changedAddresses returns the sorted addresses of all resource changes whose actions match the filter
+func changedAddresses(planStruct *terraform.PlanStruct, filter func(tfjson.Actions) bool) []string {
+	var addresses []string
+	for address, change := range planStruct.ResourceChangesMap {
+		if change.Change != nil && filter(change.Change.Actions) {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Strings(addresses)
+	return addresses
+}
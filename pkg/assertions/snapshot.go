@@ -0,0 +1,274 @@
+package assertions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/glob"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	// volatilePlaceholder replaces the values of volatile attributes in snapshots
+	volatilePlaceholder = "<volatile>"
+	// computedPlaceholder replaces planned values that are only known after apply
+	computedPlaceholder = "<computed>"
+	// workspacePlaceholder replaces the directory Terraform ran in within string values
+	workspacePlaceholder = "<workspace>"
+)
+
+// DefaultSnapshotIgnore lists the volatile attributes replaced in every snapshot: ids, ARNs and timestamps
+// Rules without a dot match attribute names at any depth; see testctx.TestConfig.SnapshotIgnore
+var DefaultSnapshotIgnore = []string{"id", "*_id", "arn", "*_arn", "*timestamp*", "*_at", "*_time"}
+
+// snapshot is the serialized form of an example compared by AssertMatchesSnapshot
+type snapshot struct {
+	Outputs map[string]interface{}            `json:"outputs,omitempty"`
+	Plan    map[string]snapshotChange         `json:"plan,omitempty"`
+	State   map[string]map[string]interface{} `json:"state,omitempty"`
+}
+
+// snapshotChange is a planned resource change in a snapshot
+type snapshotChange struct {
+	Actions []string    `json:"actions"`
+	After   interface{} `json:"after,omitempty"`
+}
+
+// AssertMatchesSnapshot compares the outputs and the normalized state of an example with the snapshot stored in
+// __snapshots__/<name>.json next to the test, or its planned changes and output values in plan-only mode with the
+// snapshot stored in __snapshots__/<name>.plan.json
+// Volatile attributes (DefaultSnapshotIgnore and ctx.Config.SnapshotIgnore) are replaced by a placeholder.
+// Snapshots are created and rewritten with tftest run --update-snapshots; a missing snapshot fails otherwise,
+// so a checkout without its snapshots committed cannot pass without comparing anything
+func AssertMatchesSnapshot(t testing.TB, ctx testctx.TestContext, name string) {
+	actual, err := buildSnapshot(t, ctx)
+	if !assert.NoError(t, err, "Snapshot %s should be built", name) {
+		return
+	}
+
+	path := testctx.SnapshotFile(name)
+	if ctx.Plan != nil {
+		path = testctx.PlanSnapshotFile(name)
+	}
+	expected, err := os.ReadFile(path)
+	missing := errors.Is(err, os.ErrNotExist)
+	if testctx.UpdateSnapshotsEnabled() {
+		if err := writeSnapshot(path, actual); !assert.NoError(t, err, "Snapshot %s should be written", path) {
+			return
+		}
+		if missing {
+			t.Logf("Created snapshot %s", path)
+		} else {
+			t.Logf("Updated snapshot %s", path)
+		}
+		return
+	}
+	if missing {
+		assert.Fail(t, fmt.Sprintf("Snapshot %s of %s does not exist, run tftest run --update-snapshots to create it", path, ctx.Name))
+		return
+	}
+	if !assert.NoError(t, err, "Snapshot %s should be readable", path) {
+		return
+	}
+
+	assert.Equal(t, string(expected), string(actual),
+		"Example %s should match snapshot %s, run tftest run --update-snapshots to accept the changes", ctx.Name, path)
+}
+
+// buildSnapshot serializes the normalized outputs and state or plan of a context
+func buildSnapshot(t testing.TB, ctx testctx.TestContext) ([]byte, error) {
+	normalizer := snapshotNormalizer{
+		rules: append(append([]string{}, DefaultSnapshotIgnore...), ctx.Config.SnapshotIgnore...),
+	}
+	if ctx.Terraform != nil {
+		normalizer.workspace = ctx.Terraform.TerraformDir
+	}
+
+	var snap snapshot
+	if ctx.Plan != nil {
+		snap.Plan = map[string]snapshotChange{}
+		for _, change := range ctx.Plan.RawPlan.ResourceChanges {
+			if change.Change == nil || change.Mode == tfjson.DataResourceMode {
+				continue
+			}
+			actions := make([]string, 0, len(change.Change.Actions))
+			for _, action := range change.Change.Actions {
+				actions = append(actions, string(action))
+			}
+			after := markComputed(change.Change.After, change.Change.AfterUnknown)
+			snap.Plan[change.Address] = snapshotChange{
+				Actions: actions,
+				After:   normalizer.normalize(change.Address, after),
+			}
+		}
+
+		if ctx.Plan.RawPlan.PlannedValues != nil && len(ctx.Plan.RawPlan.PlannedValues.Outputs) > 0 {
+			snap.Outputs = map[string]interface{}{}
+			for name, output := range ctx.Plan.RawPlan.PlannedValues.Outputs {
+				value := output.Value
+				if value == nil && !output.Sensitive {
+					value = computedPlaceholder
+				}
+				snap.Outputs[name] = normalizer.normalizeOutput(name, value)
+			}
+		}
+	} else {
+		outputs, err := ctx.OutputsE(t)
+		if err != nil {
+			return nil, err
+		}
+		snap.Outputs = map[string]interface{}{}
+		for name, raw := range outputs {
+			var value interface{}
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("failed to parse output %s: %w", name, err)
+			}
+			snap.Outputs[name] = normalizer.normalizeOutput(name, value)
+		}
+
+		snap.State = map[string]map[string]interface{}{}
+		for _, resource := range ctx.GetState(t).Resources {
+			if resource.Mode == string(tfjson.DataResourceMode) {
+				continue
+			}
+			attributes, _ := normalizer.normalize(resource.Address, resource.Attributes).(map[string]interface{})
+			snap.State[resource.Address] = attributes
+		}
+	}
+
+	// Reading the outputs does not record them for tftest coverage, comparing them with the snapshot does
+	outputNames := make([]string, 0, len(snap.Outputs))
+	for name := range snap.Outputs {
+		outputNames = append(outputNames, name)
+	}
+	sort.Strings(outputNames)
+	ctx.RecordOutputs(outputNames...)
+
+	// Keep placeholders such as <volatile> readable instead of escaping them as \u003c
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(snap); err != nil {
+		return nil, err
+	}
+	return content.Bytes(), nil
+}
+
+// writeSnapshot writes a snapshot file, creating the snapshot directory if needed
+func writeSnapshot(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// snapshotNormalizer replaces volatile values in snapshots
+type snapshotNormalizer struct {
+	// rules are globs matched against attribute names, or against full paths if they contain a dot
+	rules []string
+	// workspace is the directory Terraform ran in, replaced in string values
+	workspace string
+}
+
+// normalizeOutput normalizes the value of an output, which is volatile as a whole if its name matches a rule
+func (n snapshotNormalizer) normalizeOutput(name string, value interface{}) interface{} {
+	if n.volatile(name, "output."+name) {
+		return volatilePlaceholder
+	}
+	return n.normalize("output."+name, value)
+}
+
+// normalize returns a copy of value with volatile attributes and workspace paths replaced
+// path is the dotted path of value, e.g. "module.x.aws_s3_bucket.b.tags" or "output.json_data"
+func (n snapshotNormalizer) normalize(path string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			childPath := path + "." + key
+			if n.volatile(key, childPath) {
+				normalized[key] = volatilePlaceholder
+				continue
+			}
+			normalized[key] = n.normalize(childPath, child)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, child := range v {
+			childPath := path + "." + strconv.Itoa(i)
+			if n.volatile("", childPath) {
+				normalized[i] = volatilePlaceholder
+				continue
+			}
+			normalized[i] = n.normalize(childPath, child)
+		}
+		return normalized
+	case string:
+		if n.workspace != "" {
+			return strings.ReplaceAll(v, n.workspace, workspacePlaceholder)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// volatile reports whether an attribute name or full path matches a rule
+func (n snapshotNormalizer) volatile(name, path string) bool {
+	for _, rule := range n.rules {
+		if strings.Contains(rule, ".") {
+			if glob.Match(rule, path) {
+				return true
+			}
+		} else if name != "" && glob.Match(rule, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// markComputed replaces the values marked as unknown in after_unknown with a placeholder
+func markComputed(after, unknown interface{}) interface{} {
+	if known, ok := unknown.(bool); ok && known {
+		return computedPlaceholder
+	}
+
+	switch u := unknown.(type) {
+	case map[string]interface{}:
+		values, _ := after.(map[string]interface{})
+		merged := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			merged[key] = value
+		}
+		for key, childUnknown := range u {
+			if isUnknown, ok := childUnknown.(bool); ok && !isUnknown {
+				continue
+			}
+			merged[key] = markComputed(merged[key], childUnknown)
+		}
+		return merged
+	case []interface{}:
+		values, _ := after.([]interface{})
+		merged := make([]interface{}, len(values))
+		copy(merged, values)
+		for i, childUnknown := range u {
+			if i < len(merged) {
+				merged[i] = markComputed(merged[i], childUnknown)
+			}
+		}
+		return merged
+	}
+	return after
+}
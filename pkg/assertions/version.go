@@ -0,0 +1,155 @@
+package assertions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/tfconfig"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+)
+
+// AssertTerraformVersion checks if the Terraform version satisfies a version constraint
+// The constraint uses Terraform's syntax, e.g. "~> 1.5" or ">= 1.3, < 2.0"; a bare version such as "1.5.0"
+// is a minimum version and means ">= 1.5.0"
+func AssertTerraformVersion(t testing.TB, ctx testctx.TestContext, constraint string) {
+	info, err := ctx.GetExecutor().Version(t, ctx.Terraform)
+	if !assert.NoError(t, err, "Terraform version should not fail") {
+		return
+	}
+
+	satisfied, err := versionSatisfies(info.Version, minimumConstraint(constraint))
+	if !assert.NoError(t, err, "Terraform version constraint should be valid") {
+		return
+	}
+	assert.True(t, satisfied, "Terraform version %s should satisfy %s", info.Version, constraint)
+}
+
+// AssertProviderVersion checks if the version of a provider selected by terraform init satisfies a version constraint
+// The provider is given by its local name (aws), source (hashicorp/aws) or full address (registry.terraform.io/hashicorp/aws)
+// The example must be initialized, as the selected versions are read from terraform version -json
+func AssertProviderVersion(t testing.TB, ctx testctx.TestContext, provider string, constraint string) {
+	info, err := ctx.GetExecutor().Version(t, ctx.Terraform)
+	if !assert.NoError(t, err, "Terraform version should not fail") {
+		return
+	}
+
+	address := tfconfig.ProviderAddress(provider, providerSource(provider))
+	selected, exists := info.ProviderSelections[address]
+	if !assert.True(t, exists, "Provider %s should be installed, selected providers: %s", address, providerList(info.ProviderSelections)) {
+		return
+	}
+
+	satisfied, err := versionSatisfies(selected, minimumConstraint(constraint))
+	if !assert.NoError(t, err, "Provider version constraint should be valid") {
+		return
+	}
+	assert.True(t, satisfied, "Provider %s version %s should satisfy %s", address, selected, constraint)
+}
+
+// AssertRequiredVersionSatisfied checks that the running binary and the selected providers satisfy the
+// required_version and required_providers constraints of the example and of the local modules it calls
+// Providers that are not installed yet (e.g. before init) are reported as failures
+func AssertRequiredVersionSatisfied(t testing.TB, ctx testctx.TestContext) {
+	dir := ctx.ExamplePath
+	if ctx.Terraform != nil && ctx.Terraform.TerraformDir != "" {
+		dir = ctx.Terraform.TerraformDir
+	}
+
+	modules, err := tfconfig.LoadModuleTree(dir)
+	if !assert.NoError(t, err, "Terraform configuration of %s should be readable", dir) {
+		return
+	}
+
+	info, err := ctx.GetExecutor().Version(t, ctx.Terraform)
+	if !assert.NoError(t, err, "Terraform version should not fail") {
+		return
+	}
+
+	for _, module := range modules {
+		for _, constraint := range module.RequiredVersions {
+			satisfied, err := versionSatisfies(info.Version, constraint)
+			if !assert.NoError(t, err, "required_version in %s should be valid", module.Dir) {
+				continue
+			}
+			assert.True(t, satisfied, "Terraform version %s should satisfy required_version %q in %s", info.Version, constraint, module.Dir)
+		}
+
+		for _, name := range sortedKeys(module.RequiredProviders) {
+			requirement := module.RequiredProviders[name]
+			if len(requirement.Versions) == 0 {
+				continue
+			}
+
+			address := requirement.Address(name)
+			selected, exists := info.ProviderSelections[address]
+			if !assert.True(t, exists, "Provider %s required in %s should be installed, selected providers: %s",
+				address, module.Dir, providerList(info.ProviderSelections)) {
+				continue
+			}
+
+			for _, constraint := range requirement.Versions {
+				satisfied, err := versionSatisfies(selected, constraint)
+				if !assert.NoError(t, err, "Version constraint of provider %s in %s should be valid", address, module.Dir) {
+					continue
+				}
+				assert.True(t, satisfied, "Provider %s version %s should satisfy %q required in %s", address, selected, constraint, module.Dir)
+			}
+		}
+	}
+}
+
+// versionSatisfies checks a version against a constraint in Terraform's syntax
+func versionSatisfies(v string, constraint string) (bool, error) {
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", v, err)
+	}
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	return constraints.Check(parsed), nil
+}
+
+// minimumConstraint turns a bare version into a minimum version constraint and returns constraints unchanged
+func minimumConstraint(constraint string) string {
+	constraint = strings.TrimSpace(constraint)
+	if _, err := version.NewVersion(constraint); err == nil {
+		return ">= " + strings.TrimPrefix(constraint, "v")
+	}
+	return constraint
+}
+
+// providerSource returns the source part of a provider given as a source or address, or "" for a local name
+func providerSource(provider string) string {
+	if strings.Contains(provider, "/") {
+		return provider
+	}
+	return ""
+}
+
+// providerList formats the selected providers for failure messages
+func providerList(selections map[string]string) string {
+	if len(selections) == 0 {
+		return "none (has the example been initialized?)"
+	}
+	var providers []string
+	for _, address := range sortedKeys(selections) {
+		providers = append(providers, address+" "+selections[address])
+	}
+	return strings.Join(providers, ", ")
+}
+
+// sortedKeys returns the keys of a map in alphabetical order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
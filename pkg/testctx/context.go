@@ -1,8 +1,12 @@
 package testctx
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
 )
@@ -11,6 +15,44 @@ import (
 type TestConfig struct {
 	Name      string
 	ExtraVars map[string]interface{}
+	// PlanOnly runs init and plan instead of apply/destroy (see RunExamplePlanOnly)
+	PlanOnly bool
+	// IdempotencyIgnore lists expected perpetual diffs that should not fail the idempotency check
+	IdempotencyIgnore []IdempotencyIgnoreRule
+	// MaxApplies is the number of applies allowed for the config to converge (default 1)
+	// Use it for modules that only converge after a second apply, e.g. data sources reading resources from the same run
+	MaxApplies int
+	// UpgradeAllowlist lists resource address globs that may be replaced or destroyed by an upgrade (see RunUpgradeTest)
+	UpgradeAllowlist []string
+	// Workspace selects where Terraform runs; by default examples run in an isolated copy when tests run in parallel
+	Workspace WorkspaceMode
+	// Binary selects the CLI: "terraform", "tofu" or a path to a binary (default: TERRATEST_BINARY, then terraform)
+	Binary string
+	// Executor overrides the executor used to run the CLI, e.g. to inject a fake in unit tests
+	Executor Executor
+	// SnapshotIgnore lists volatile attributes replaced by a placeholder in snapshots, in addition to ids and timestamps
+	// Rules without a dot match attribute and output names (e.g. "etag"), rules with a dot match full paths
+	// (e.g. "module.example.time_static.*" or "output.build_info.*")
+	SnapshotIgnore []string
+	// VarFiles are passed to Terraform as -var-file, relative to the example directory
+	VarFiles []string
+	// EnvVars holds the environment variables Terraform runs with
+	EnvVars map[string]string
+	// Timeout fails RunExample before its next stage once the example has run longer (default: no timeout)
+	Timeout time.Duration
+	// DisableIdempotency skips the idempotency check of RunExample for this config
+	DisableIdempotency bool
+	// ExpectedOutputs are compared with the outputs of the example by RunExample after apply
+	ExpectedOutputs map[string]interface{}
+}
+
+// IdempotencyIgnoreRule describes an expected perpetual diff for the idempotency check
+// Both fields support globs where '*' matches any sequence of characters and '?' a single character
+type IdempotencyIgnoreRule struct {
+	// Address matches resource addresses (e.g. "module.example.aws_s3_bucket.*") or outputs ("output.timestamp")
+	Address string
+	// Attributes matches dotted attribute paths (e.g. "tags_all.*"); leave empty to ignore every change to the resource
+	Attributes []string
 }
 
 // TestContext combines test configuration with terraform options
@@ -20,11 +62,67 @@ type TestContext struct {
 	ExamplePath   string
 	Name          string
 	TerraformVars map[string]interface{}
+	// Plan holds the parsed plan when the example was run in plan-only mode
+	Plan *terraform.PlanStruct
+	// WorkspacePath is the isolated copy of the example Terraform runs in, empty when running in place
+	WorkspacePath string
+	// Executor runs the Terraform CLI commands for this context (see NewExecutor)
+	Executor Executor
+
+	// outputs caches the outputs after the first read (see OutputsE)
+	outputs *outputCache
+
+	// workspaceRoot is the temporary directory holding the isolated copy, removed after destroy
+	workspaceRoot string
 }
 
 // GetOutput retrieves a terraform output value by key
 func (ctx TestContext) GetOutput(t testing.TB, key string) string {
-	return terraform.Output(t, ctx.Terraform, key)
+	var value interface{}
+	ctx.decodeOutput(t, key, &value)
+	return fmt.Sprintf("%v", value)
+}
+
+// GetOutputMap retrieves a terraform map output with every value formatted as a string
+func (ctx TestContext) GetOutputMap(t testing.TB, key string) map[string]string {
+	var value map[string]interface{}
+	ctx.decodeOutput(t, key, &value)
+
+	result := make(map[string]string, len(value))
+	for k, v := range value {
+		result[k] = fmt.Sprintf("%v", v)
+	}
+	return result
+}
+
+// GetOutputList retrieves a terraform list output with every element formatted as a string
+func (ctx TestContext) GetOutputList(t testing.TB, key string) []string {
+	var value []interface{}
+	ctx.decodeOutput(t, key, &value)
+
+	result := make([]string, 0, len(value))
+	for _, v := range value {
+		result = append(result, fmt.Sprintf("%v", v))
+	}
+	return result
+}
+
+// GetAllOutputs retrieves the values of all terraform outputs
+func (ctx TestContext) GetAllOutputs(t testing.TB) map[string]interface{} {
+	outputs, err := ctx.OutputsE(t)
+	if err != nil {
+		t.Fatalf("Failed to read outputs of %s: %v", ctx.Name, err)
+	}
+
+	values := make(map[string]interface{}, len(outputs))
+	for name, value := range outputs {
+		var decoded interface{}
+		if err := json.Unmarshal(value, &decoded); err != nil {
+			t.Fatalf("Failed to parse output %s of %s: %v", name, ctx.Name, err)
+		}
+		values[name] = decoded
+	}
+	return values
 }
 
 // GetTerraform returns the terraform options
@@ -32,6 +130,25 @@ func (ctx TestContext) GetTerraform() *terraform.Options {
 	return ctx.Terraform
 }
 
+// GetExecutor returns the executor of the context, or the default executor for its config if none is set
+func (ctx TestContext) GetExecutor() Executor {
+	if ctx.Executor != nil {
+		return ctx.Executor
+	}
+	return NewExecutor(ctx.Config)
+}
+
+// decodeOutput reads a single output and decodes its JSON value into v
+func (ctx TestContext) decodeOutput(t testing.TB, key string, v interface{}) {
+	value, err := ctx.OutputE(t, key)
+	if err != nil {
+		t.Fatalf("Failed to read output %s of %s: %v", key, ctx.Name, err)
+	}
+	if err := json.Unmarshal(value, v); err != nil {
+		t.Fatalf("Failed to parse output %s of %s: %v", key, ctx.Name, err)
+	}
+}
+
 // GetVariableAsMap returns the variables from the terraform options
 // This is a compatibility function to replace the removed terraform.GetVariableAsMap
 func (ctx TestContext) GetVariableAsMap() map[string]interface{} {
@@ -51,6 +168,7 @@ func NewTestContext(examplePath string, vars map[string]interface{}) TestContext
 		Terraform:     &terraform.Options{},
 		TerraformVars: vars,
 		ExamplePath:   examplePath,
+		outputs:       newOutputCache(),
 	}
 }
 
@@ -65,3 +183,11 @@ func IdempotencyEnabled() bool {
 	val := os.Getenv("TERRATEST_IDEMPOTENCY")
 	return val != "false"
 }
+
+// PlanOnlyEnabled checks if plan-only mode is enabled for all examples
+// Returns true only if TERRATEST_PLAN_ONLY is set to "true"
+// This is set by the tftest CLI when running with --plan-only
+func PlanOnlyEnabled() bool {
+	val := os.Getenv("TERRATEST_PLAN_ONLY")
+	return strings.EqualFold(val, "true")
+}
@@ -0,0 +1,108 @@
+package testctx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ConvergenceResult records the outcome of a multi-apply convergence check
+type ConvergenceResult struct {
+	// Converged is true if a plan reported no changes within the allowed number of applies
+	Converged bool
+	// Applies is the apply number after which the config converged, or the number of applies made if it never did
+	Applies int
+	// MaxApplies is the maximum number of applies that were allowed
+	MaxApplies int
+	// Diffs holds the plan diff recorded after each apply, in order
+	Diffs []PlanDiff
+}
+
+// String returns a readable report of the plan diff recorded after each apply
+func (r ConvergenceResult) String() string {
+	var b strings.Builder
+	if r.Converged {
+		fmt.Fprintf(&b, "Converged after apply %d of %d", r.Applies, r.MaxApplies)
+	} else {
+		fmt.Fprintf(&b, "Did not converge after %d apply(s)", r.Applies)
+	}
+
+	for i, diff := range r.Diffs {
+		if !diff.HasChanges() {
+			continue
+		}
+		fmt.Fprintf(&b, "\nPlan after apply %d: %s", i+1, diff)
+	}
+	return b.String()
+}
+
+// MaxApplies returns the number of applies allowed for the convergence check of the given config
+// Defaults to 1, which is the classic single plan-after-apply idempotency check
+func MaxApplies(config TestConfig) int {
+	if config.MaxApplies < 1 {
+		return 1
+	}
+	return config.MaxApplies
+}
+
+// CheckConvergenceE plans after the initial apply and re-applies until the plan reports no changes
+// or maxApplies applies have been made. The first apply must already have happened
+func CheckConvergenceE(t testing.TB, ctx TestContext, maxApplies int) (ConvergenceResult, error) {
+	result := ConvergenceResult{MaxApplies: maxApplies}
+
+	for apply := 1; ; apply++ {
+		result.Applies = apply
+
+		diff, err := CheckIdempotencyE(t, ctx)
+		if err != nil {
+			return result, err
+		}
+		result.Diffs = append(result.Diffs, diff)
+
+		if !diff.HasChanges() {
+			result.Converged = true
+			return result, nil
+		}
+
+		if apply >= maxApplies {
+			return result, nil
+		}
+
+		t.Logf("Plan after apply %d of %d still has changes for %s, applying again", apply, maxApplies, ctx.Name)
+		ctx.ResetOutputs()
+		if _, err := ctx.GetExecutor().Apply(t, ctx.Terraform); err != nil {
+			return result, err
+		}
+	}
+}
+
+// CheckConvergence verifies that the example converges within ctx.Config.MaxApplies applies
+// With the default of a single apply this is the same as CheckIdempotency
+func CheckConvergence(t testing.TB, ctx TestContext) bool {
+	maxApplies := MaxApplies(ctx.Config)
+	if maxApplies == 1 {
+		return CheckIdempotency(t, ctx)
+	}
+
+	t.Logf("Running convergence test for %s with up to %d applies", ctx.Name, maxApplies)
+
+	result, err := CheckConvergenceE(t, ctx, maxApplies)
+	for _, diff := range result.Diffs {
+		if len(diff.Ignored) > 0 {
+			t.Logf("Ignoring expected diffs for %s: %s", ctx.Name, diff.IgnoredString())
+		}
+	}
+
+	if err != nil {
+		t.Errorf("Convergence test failed for %s after apply %d: %v", ctx.Name, result.Applies, err)
+		return false
+	}
+
+	if !result.Converged {
+		t.Errorf("Convergence test failed for %s: %s", ctx.Name, result)
+		return false
+	}
+
+	t.Logf("Convergence test passed for %s: %s", ctx.Name, result)
+	return true
+}
@@ -0,0 +1,82 @@
+package testctx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/coverage"
+)
+
+// CoverageDir returns the directory the vars passed to examples and the outputs read by tests are recorded to
+// It is set by tftest run --coverage via TERRATEST_COVERAGE_DIR; nothing is recorded when it is empty
+func CoverageDir() string {
+	return strings.TrimSpace(os.Getenv("TERRATEST_COVERAGE_DIR"))
+}
+
+// recordedCoverage holds the entries already recorded by this process, so repeated reads are only logged once
+var recordedCoverage = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: map[string]bool{}}
+
+// recordVars records the names of the vars passed to an example for tftest coverage
+func recordVars(examplePath string, vars map[string]interface{}) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	recordCoverage(examplePath, "var", names)
+}
+
+// recordOutputs records the names of the outputs of an example read by a test for tftest coverage
+func recordOutputs(examplePath string, names ...string) {
+	recordCoverage(examplePath, "output", names)
+}
+
+// RecordOutputs marks outputs of the context's example as asserted for tftest coverage
+// Single output reads record themselves; use it when a test checks outputs read with OutputsE or GetAllOutputs
+func (ctx TestContext) RecordOutputs(names ...string) {
+	recordOutputs(ctx.ExamplePath, names...)
+}
+
+// recordCoverage appends the names not recorded before to the coverage log
+// Failures to record are reported on stderr instead of failing the test
+func recordCoverage(examplePath, kind string, names []string) {
+	dir := CoverageDir()
+	if dir == "" || examplePath == "" || len(names) == 0 {
+		return
+	}
+	absPath, err := filepath.Abs(examplePath)
+	if err != nil {
+		absPath = examplePath
+	}
+
+	recordedCoverage.Lock()
+	var fresh []string
+	for _, name := range names {
+		key := absPath + "\x00" + kind + "\x00" + name
+		if !recordedCoverage.seen[key] {
+			recordedCoverage.seen[key] = true
+			fresh = append(fresh, name)
+		}
+	}
+	recordedCoverage.Unlock()
+	if len(fresh) == 0 {
+		return
+	}
+	sort.Strings(fresh)
+
+	record := coverage.Record{Example: absPath}
+	if kind == "var" {
+		record.Vars = fresh
+	} else {
+		record.Outputs = fresh
+	}
+	if err := coverage.Append(dir, record); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to record coverage of %s: %v\n", examplePath, err)
+	}
+}
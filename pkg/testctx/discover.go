@@ -3,10 +3,15 @@ package testctx
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
 )
 
 // DiscoverExamples finds all examples in the given directory
+// Examples not selected by tftest run (see ExampleSelected) are left out. The tftest.yaml manifest of each example
+// is loaded, failing the test if it is invalid, and every example is returned after the examples it depends on
 func DiscoverExamples(t *testing.T, moduleRootPath string) []string {
 	entries, err := os.ReadDir(moduleRootPath)
 	if err != nil {
@@ -14,16 +19,60 @@ func DiscoverExamples(t *testing.T, moduleRootPath string) []string {
 	}
 
 	var examples []string
+	dependencies := map[string][]string{}
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
 		}
 
 		// Only include directories that start with "example-"
-		if filepath.Base(entry.Name())[:8] == "example-" {
+		if strings.HasPrefix(entry.Name(), "example-") && ExampleSelected(entry.Name()) {
+			m, err := LoadManifest(filepath.Join(moduleRootPath, entry.Name()))
+			if err != nil {
+				t.Fatalf("Failed to load the manifest of %s: %v", entry.Name(), err)
+			}
 			examples = append(examples, entry.Name())
+			dependencies[entry.Name()] = m.DependsOn
 		}
 	}
 
+	examples, err = manifest.Order(examples, dependencies)
+	if err != nil {
+		t.Fatalf("Failed to order examples: %v", err)
+	}
 	return examples
 }
+
+// SelectedExamples returns the examples selected by tftest run with --example-path, --exclude-example or --tags
+// It is read from TERRATEST_EXAMPLES and is nil when every example is selected
+func SelectedExamples() []string {
+	val := strings.TrimSpace(os.Getenv("TERRATEST_EXAMPLES"))
+	if val == "" {
+		return nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(val, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ExampleSelected reports whether an example runs in this test run
+// Directory names prefixed with "example-" also match the selection without the prefix
+func ExampleSelected(name string) bool {
+	selected := SelectedExamples()
+	if selected == nil {
+		return true
+	}
+
+	name = filepath.Base(name)
+	for _, candidate := range selected {
+		if candidate == name || candidate == strings.TrimPrefix(name, "example-") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package testctx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Executor runs the Terraform CLI commands used by the framework
+// Every method receives the terraform options of the workspace it runs in, so one executor can serve many examples
+type Executor interface {
+	// Init runs init in options.TerraformDir
+	Init(t terratesting.TestingT, options *terraform.Options) (string, error)
+	// Apply runs apply -auto-approve with the vars and var files of options
+	Apply(t terratesting.TestingT, options *terraform.Options) (string, error)
+	// Plan runs plan -detailed-exitcode, writing the plan to options.PlanFilePath if set
+	// It returns the exit code: PlanExitCodeNoChanges or PlanExitCodeChanges, and an error if the plan failed
+	Plan(t terratesting.TestingT, options *terraform.Options) (int, error)
+	// Show renders the plan at options.PlanFilePath, or the current state if it is empty, as JSON
+	Show(t terratesting.TestingT, options *terraform.Options) (string, error)
+	// Output returns the JSON of output -json for key, or of all outputs if key is empty
+	Output(t terratesting.TestingT, options *terraform.Options, key string) (string, error)
+	// Destroy runs destroy -auto-approve
+	Destroy(t terratesting.TestingT, options *terraform.Options) (string, error)
+	// StateList returns the resource addresses in the state
+	StateList(t terratesting.TestingT, options *terraform.Options) ([]string, error)
+	// Version returns the CLI and provider versions reported by version -json
+	Version(t terratesting.TestingT, options *terraform.Options) (VersionInfo, error)
+	// PlanJSON runs plan -json and returns its machine readable output, which is also returned if the plan fails
+	PlanJSON(t terratesting.TestingT, options *terraform.Options) (string, error)
+	// ApplyJSON runs apply -auto-approve -json and returns its machine readable output, which is also returned
+	// if the apply fails
+	ApplyJSON(t terratesting.TestingT, options *terraform.Options) (string, error)
+}
+
+// VersionInfo is the parsed output of version -json
+type VersionInfo struct {
+	// Version is the CLI version without the leading "v", e.g. "1.9.0"
+	Version  string `json:"terraform_version"`
+	Platform string `json:"platform"`
+	// ProviderSelections maps provider source addresses to the selected versions of the initialized workspace
+	ProviderSelections map[string]string `json:"provider_selections"`
+}
+
+const (
+	// BinaryTerraform is the name of the Terraform CLI binary
+	BinaryTerraform = "terraform"
+	// BinaryTofu is the name of the OpenTofu CLI binary
+	BinaryTofu = "tofu"
+)
+
+// CLIExecutor runs a Terraform compatible CLI through terratest
+type CLIExecutor struct {
+	// Binary is the name or path of the CLI binary; empty keeps options.TerraformBinary (terratest's default)
+	Binary string
+}
+
+// NewTerraformExecutor returns an executor running the terraform binary, or the binary at binaryPath if set
+func NewTerraformExecutor(binaryPath string) *CLIExecutor {
+	if binaryPath == "" {
+		binaryPath = BinaryTerraform
+	}
+	return &CLIExecutor{Binary: binaryPath}
+}
+
+// NewTofuExecutor returns an executor running the OpenTofu binary, or the binary at binaryPath if set
+func NewTofuExecutor(binaryPath string) *CLIExecutor {
+	if binaryPath == "" {
+		binaryPath = BinaryTofu
+	}
+	return &CLIExecutor{Binary: binaryPath}
+}
+
+// NewExecutor returns the executor for a test config
+// config.Executor is used if set. Otherwise the binary is taken from config.Binary, or TERRATEST_BINARY
+// (set by tftest run --binary), and may be "terraform", "tofu" or a path to either binary
+func NewExecutor(config TestConfig) Executor {
+	if config.Executor != nil {
+		return config.Executor
+	}
+
+	binary := ConfiguredBinary(config)
+	switch {
+	case binary == "":
+		// Let terratest pick terraform, falling back to tofu if terraform is not installed
+		return &CLIExecutor{}
+	case strings.HasPrefix(filepath.Base(binary), BinaryTofu):
+		return NewTofuExecutor(binary)
+	default:
+		return NewTerraformExecutor(binary)
+	}
+}
+
+// ConfiguredBinary returns the CLI binary selected by config.Binary or the TERRATEST_BINARY environment variable
+// An empty result means terratest's default binary is used
+func ConfiguredBinary(config TestConfig) string {
+	if config.Binary != "" {
+		return config.Binary
+	}
+	return os.Getenv("TERRATEST_BINARY")
+}
+
+// Init runs terraform init
+func (e *CLIExecutor) Init(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return terraform.InitE(t, e.options(options))
+}
+
+// Apply runs terraform apply
+func (e *CLIExecutor) Apply(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return terraform.ApplyE(t, e.options(options))
+}
+
+// Plan runs terraform plan -detailed-exitcode
+func (e *CLIExecutor) Plan(t terratesting.TestingT, options *terraform.Options) (int, error) {
+	opts := e.options(options)
+	args := append([]string{"plan", "-input=false", "-detailed-exitcode"}, opts.ExtraArgs.Plan...)
+
+	exitCode, err := terraform.GetExitCodeForTerraformCommandE(t, opts, terraform.FormatArgs(opts, args...)...)
+	if err != nil {
+		return exitCode, err
+	}
+	if exitCode != PlanExitCodeNoChanges && exitCode != PlanExitCodeChanges {
+		return exitCode, fmt.Errorf("%s plan failed with exit code %d", e.name(opts), exitCode)
+	}
+	return exitCode, nil
+}
+
+// Show runs terraform show -json
+func (e *CLIExecutor) Show(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return terraform.ShowE(t, e.options(options))
+}
+
+// Output runs terraform output -json
+func (e *CLIExecutor) Output(t terratesting.TestingT, options *terraform.Options, key string) (string, error) {
+	return terraform.OutputJsonE(t, e.options(options), key)
+}
+
+// Destroy runs terraform destroy
+func (e *CLIExecutor) Destroy(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return terraform.DestroyE(t, e.options(options))
+}
+
+// StateList runs terraform state list
+func (e *CLIExecutor) StateList(t terratesting.TestingT, options *terraform.Options) ([]string, error) {
+	output, err := terraform.RunTerraformCommandAndGetStdoutE(t, e.options(options), "state", "list")
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			addresses = append(addresses, line)
+		}
+	}
+	return addresses, nil
+}
+
+// Version runs terraform version -json
+func (e *CLIExecutor) Version(t terratesting.TestingT, options *terraform.Options) (VersionInfo, error) {
+	output, err := terraform.RunTerraformCommandAndGetStdoutE(t, e.options(options), "version", "-json")
+	if err != nil {
+		return VersionInfo{}, err
+	}
+	return ParseVersionJSON(output)
+}
+
+// PlanJSON runs terraform plan -json
+func (e *CLIExecutor) PlanJSON(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	opts := e.options(options)
+	args := append([]string{"plan", "-input=false", "-json"}, opts.ExtraArgs.Plan...)
+	return terraform.RunTerraformCommandAndGetStdoutE(t, opts, terraform.FormatArgs(opts, args...)...)
+}
+
+// ApplyJSON runs terraform apply -auto-approve -json
+func (e *CLIExecutor) ApplyJSON(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	opts := e.options(options)
+	args := append([]string{"apply", "-input=false", "-auto-approve", "-json"}, opts.ExtraArgs.Apply...)
+	return terraform.RunTerraformCommandAndGetStdoutE(t, opts, terraform.FormatArgs(opts, args...)...)
+}
+
+// ParseVersionJSON parses the output of terraform version -json (or tofu version -json)
+func ParseVersionJSON(output string) (VersionInfo, error) {
+	var info VersionInfo
+	if err := json.Unmarshal([]byte(output), &info); err != nil {
+		return VersionInfo{}, fmt.Errorf("failed to parse version output: %w", err)
+	}
+	if info.Version == "" {
+		return VersionInfo{}, errors.New("version output does not contain a version")
+	}
+	info.Version = strings.TrimPrefix(info.Version, "v")
+	return info, nil
+}
+
+// options returns the options to run with, using a copy with the executor's binary if one is set
+func (e *CLIExecutor) options(options *terraform.Options) *terraform.Options {
+	if e.Binary == "" || options.TerraformBinary == e.Binary {
+		return options
+	}
+	opts := *options
+	opts.TerraformBinary = e.Binary
+	return &opts
+}
+
+// name returns the binary name used in error messages
+func (e *CLIExecutor) name(options *terraform.Options) string {
+	if options.TerraformBinary != "" {
+		return filepath.Base(options.TerraformBinary)
+	}
+	return BinaryTerraform
+}
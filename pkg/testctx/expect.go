@@ -0,0 +1,208 @@
+package testctx
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/glob"
+)
+
+const (
+	// SeverityError is the severity of diagnostics that fail a plan or apply
+	SeverityError = "error"
+	// SeverityWarning is the severity of diagnostics that don't fail the run, such as failed check block assertions
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is an error or warning reported in the -json output of plan or apply
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	// Address is the resource or check the diagnostic belongs to, if Terraform reports one
+	Address string `json:"address,omitempty"`
+}
+
+// String formats a diagnostic as "severity: summary: detail"
+func (d Diagnostic) String() string {
+	s := d.Severity + ": " + d.Summary
+	if d.Address != "" {
+		s += " (" + d.Address + ")"
+	}
+	if d.Detail != "" {
+		s += ": " + d.Detail
+	}
+	return s
+}
+
+// DiagnosticMatcher selects the diagnostic an expected failure must report
+// Empty fields match any diagnostic of the severity
+type DiagnosticMatcher struct {
+	// Severity is "error" (default) or "warning". With "warning" the step is not required to fail,
+	// which is how failed check block assertions are reported
+	Severity string
+	// Summary is a regular expression matched against the diagnostic summary, e.g. "Invalid value for variable"
+	Summary string
+	// Detail is a regular expression matched against the diagnostic detail, e.g. the error_message of a validation
+	Detail string
+	// Address is a glob matched against the address of the diagnostic, e.g. "module.example.aws_s3_bucket.*"
+	Address string
+}
+
+// String describes the matcher for failure messages
+func (m DiagnosticMatcher) String() string {
+	parts := []string{"severity " + m.severity()}
+	if m.Summary != "" {
+		parts = append(parts, fmt.Sprintf("summary =~ %q", m.Summary))
+	}
+	if m.Detail != "" {
+		parts = append(parts, fmt.Sprintf("detail =~ %q", m.Detail))
+	}
+	if m.Address != "" {
+		parts = append(parts, fmt.Sprintf("address %q", m.Address))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// MatchE returns the diagnostics matching the matcher, or an error if a pattern is not a valid regular expression
+func (m DiagnosticMatcher) MatchE(diagnostics []Diagnostic) ([]Diagnostic, error) {
+	summary, err := regexp.Compile(m.Summary)
+	if err != nil {
+		return nil, fmt.Errorf("invalid summary pattern: %w", err)
+	}
+	detail, err := regexp.Compile(m.Detail)
+	if err != nil {
+		return nil, fmt.Errorf("invalid detail pattern: %w", err)
+	}
+
+	var matches []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if !strings.EqualFold(diagnostic.Severity, m.severity()) {
+			continue
+		}
+		if !summary.MatchString(diagnostic.Summary) || !detail.MatchString(diagnostic.Detail) {
+			continue
+		}
+		if m.Address != "" && !glob.Match(m.Address, diagnostic.Address) {
+			continue
+		}
+		matches = append(matches, diagnostic)
+	}
+	return matches, nil
+}
+
+// severity returns the severity to match, defaulting to error
+func (m DiagnosticMatcher) severity() string {
+	if m.Severity == "" {
+		return SeverityError
+	}
+	return strings.ToLower(m.Severity)
+}
+
+// ParseDiagnostics extracts the diagnostics from the JSON lines written by plan -json or apply -json
+// Lines that are not diagnostic messages are ignored
+func ParseDiagnostics(output string) []Diagnostic {
+	var diagnostics []Diagnostic
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+
+		var message struct {
+			Type       string      `json:"type"`
+			Diagnostic *Diagnostic `json:"diagnostic"`
+		}
+		if err := json.Unmarshal([]byte(line), &message); err != nil {
+			continue
+		}
+		if message.Type == "diagnostic" && message.Diagnostic != nil {
+			diagnostics = append(diagnostics, *message.Diagnostic)
+		}
+	}
+	return diagnostics
+}
+
+// ExpectPlanFailure runs init and plan for an example and fails the test unless the plan fails
+// with a diagnostic matching the matcher. Use it to test variable validation blocks and preconditions
+// It returns the matching diagnostics
+func ExpectPlanFailure(t testing.TB, examplePath string, config TestConfig, matcher DiagnosticMatcher) []Diagnostic {
+	config = exampleConfig(t, examplePath, config)
+	ctx := runInWorkspace(t, examplePath, config)
+	initExpectedFailure(t, ctx)
+
+	output, err := ctx.GetExecutor().PlanJSON(t, ctx.Terraform)
+	return expectDiagnostic(t, ctx, "plan", output, err, matcher)
+}
+
+// ExpectApplyFailure runs init and apply for an example and fails the test unless the apply fails
+// with a diagnostic matching the matcher. Use it to test postconditions and checks that are only known after apply
+// Resources created before the failure are destroyed when the test finishes, unless the destroy stage is skipped
+// It returns the matching diagnostics
+func ExpectApplyFailure(t testing.TB, examplePath string, config TestConfig, matcher DiagnosticMatcher) []Diagnostic {
+	config = exampleConfig(t, examplePath, config)
+	ctx := runInWorkspace(t, examplePath, config)
+	initExpectedFailure(t, ctx)
+
+	if StageEnabled(StageDestroy) {
+		t.Cleanup(func() {
+			if _, err := ctx.GetExecutor().Destroy(t, ctx.Terraform); err != nil {
+				t.Errorf("Failed to destroy %s: %v", ctx.Name, err)
+			}
+		})
+	}
+
+	output, err := ctx.GetExecutor().ApplyJSON(t, ctx.Terraform)
+	return expectDiagnostic(t, ctx, "apply", output, err, matcher)
+}
+
+// initExpectedFailure runs init for an expected failure, which must succeed for the failure to be meaningful
+func initExpectedFailure(t testing.TB, ctx TestContext) {
+	if _, err := ctx.GetExecutor().Init(t, ctx.Terraform); err != nil {
+		t.Fatalf("Failed to initialize %s: %v", ctx.Name, err)
+	}
+}
+
+// expectDiagnostic checks the result of a plan or apply expected to report a matching diagnostic
+func expectDiagnostic(t testing.TB, ctx TestContext, step, output string, err error, matcher DiagnosticMatcher) []Diagnostic {
+	diagnostics := ParseDiagnostics(output)
+	if err == nil && matcher.severity() == SeverityError {
+		t.Fatalf("Expected %s of %s to fail with a diagnostic matching %s, but it succeeded%s",
+			step, ctx.Name, matcher, formatDiagnostics(diagnostics))
+	}
+
+	matches, matchErr := matcher.MatchE(diagnostics)
+	if matchErr != nil {
+		t.Fatalf("Invalid diagnostic matcher for %s: %v", ctx.Name, matchErr)
+	}
+	if len(matches) == 0 {
+		received := formatDiagnostics(diagnostics)
+		if received == "" && err != nil {
+			received = fmt.Sprintf(", the %s failed without diagnostics: %v", step, err)
+		}
+		t.Fatalf("Expected %s of %s to report a diagnostic matching %s%s", step, ctx.Name, matcher, received)
+	}
+
+	t.Logf("%s of %s reported the expected diagnostic: %s", step, ctx.Name, matches[0])
+	return matches
+}
+
+// formatDiagnostics lists the received diagnostics for a failure message
+func formatDiagnostics(diagnostics []Diagnostic) string {
+	if len(diagnostics) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(", received:")
+	for _, diagnostic := range diagnostics {
+		b.WriteString("\n  ")
+		b.WriteString(diagnostic.String())
+	}
+	return b.String()
+}
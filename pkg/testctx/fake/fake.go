@@ -0,0 +1,332 @@
+// Package fake provides a scripted testctx.Executor that replays canned command results without running Terraform
+// It records every invocation, so unit tests can exercise the runners and assertions, including failure paths
+// such as apply errors, non-idempotent plans and destroy failures, in milliseconds
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Command names used to script responses and to record calls
+const (
+	CommandInit      = "init"
+	CommandApply     = "apply"
+	CommandPlan      = "plan"
+	CommandShowPlan  = "show-plan"
+	CommandShowState = "show-state"
+	CommandOutput    = "output"
+	CommandDestroy   = "destroy"
+	CommandStateList = "state-list"
+	CommandVersion   = "version"
+	CommandPlanJSON  = "plan-json"
+	CommandApplyJSON = "apply-json"
+)
+
+// ScriptFile is the name of the script file read by Load from a fixture directory
+const ScriptFile = "script.json"
+
+// defaultResponses are served for commands without a scripted response
+var defaultResponses = map[string]Response{
+	CommandPlan:      {ExitCode: testctx.PlanExitCodeNoChanges},
+	CommandShowPlan:  {Stdout: `{"format_version": "1.2"}`},
+	CommandShowState: {Stdout: `{"format_version": "1.0"}`},
+	CommandOutput:    {Stdout: `{}`},
+	CommandVersion:   {Stdout: `{"terraform_version": "1.9.0", "platform": "linux_amd64", "provider_selections": {}}`},
+}
+
+// Response is the canned result of a single command
+type Response struct {
+	// Stdout is the output of the command: JSON for show-plan, show-state, output and version,
+	// JSON lines for plan-json and apply-json, one address per line for state-list
+	Stdout string `json:"stdout,omitempty"`
+	// File is read, relative to the script directory, and used as Stdout
+	File string `json:"file,omitempty"`
+	// ExitCode is the simulated exit code. For plan, 0 means no changes and 2 means changes;
+	// any other non-zero exit code fails the command
+	ExitCode int `json:"exit_code,omitempty"`
+	// Error is the message of the error returned for a failing command
+	Error string `json:"error,omitempty"`
+}
+
+// Call records a single invocation of the executor
+type Call struct {
+	Command string
+	// Dir is the TerraformDir of the options the command ran with
+	Dir string
+	// Key is the output name for output commands, empty when all outputs were read
+	Key string
+	// PlanFile is the PlanFilePath of the options the command ran with
+	PlanFile string
+	Vars     map[string]interface{}
+	VarFiles []string
+	EnvVars  map[string]string
+}
+
+// Executor is a scripted testctx.Executor
+// Each command serves its scripted responses in order and keeps repeating the last one; commands without
+// a scripted response succeed with an empty result (no changes, no outputs, an empty state)
+type Executor struct {
+	mu        sync.Mutex
+	responses map[string][]Response
+	calls     []Call
+}
+
+// New creates an executor without scripted responses
+func New() *Executor {
+	return &Executor{responses: map[string][]Response{}}
+}
+
+// Load reads a fixture directory holding a script.json file (see LoadE) and fails the test on errors
+func Load(t testing.TB, dir string) *Executor {
+	executor, err := LoadE(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fake executor script from %s: %v", dir, err)
+	}
+	return executor
+}
+
+// LoadE reads a fixture directory holding a script.json file that maps command names to lists of responses:
+//
+//	{
+//	  "apply": [{"exit_code": 1, "error": "Error: creating bucket"}],
+//	  "plan": [{"exit_code": 2}],
+//	  "show-plan": [{"file": "plan.json"}],
+//	  "output": [{"file": "outputs.json"}]
+//	}
+//
+// Files referenced by responses are read relative to dir
+func LoadE(dir string) (*Executor, error) {
+	content, err := os.ReadFile(filepath.Join(dir, ScriptFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var script map[string][]Response
+	if err := json.Unmarshal(content, &script); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ScriptFile, err)
+	}
+
+	executor := New()
+	for command, responses := range script {
+		if !isCommand(command) {
+			return nil, fmt.Errorf("unknown command %q in %s", command, ScriptFile)
+		}
+		for i, response := range responses {
+			if response.File == "" {
+				continue
+			}
+			stdout, err := os.ReadFile(filepath.Join(dir, response.File))
+			if err != nil {
+				return nil, err
+			}
+			responses[i].Stdout = string(stdout)
+			responses[i].File = ""
+		}
+		executor.On(command, responses...)
+	}
+	return executor, nil
+}
+
+// On appends responses to the script of a command and returns the executor for chaining
+func (e *Executor) On(command string, responses ...Response) *Executor {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.responses[command] = append(e.responses[command], responses...)
+	return e
+}
+
+// Calls returns all recorded invocations in order
+func (e *Executor) Calls() []Call {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Call(nil), e.calls...)
+}
+
+// Commands returns the names of all recorded invocations in order
+func (e *Executor) Commands() []string {
+	var commands []string
+	for _, call := range e.Calls() {
+		commands = append(commands, call.Command)
+	}
+	return commands
+}
+
+// Count returns how often a command was invoked
+func (e *Executor) Count(command string) int {
+	count := 0
+	for _, call := range e.Calls() {
+		if call.Command == command {
+			count++
+		}
+	}
+	return count
+}
+
+// Init records an init invocation
+func (e *Executor) Init(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return e.run(CommandInit, options, "")
+}
+
+// Apply records an apply invocation
+func (e *Executor) Apply(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return e.run(CommandApply, options, "")
+}
+
+// Plan records a plan invocation and returns the scripted exit code
+func (e *Executor) Plan(t terratesting.TestingT, options *terraform.Options) (int, error) {
+	response := e.record(CommandPlan, options, "")
+	exitCode := response.exitCode()
+	if exitCode != testctx.PlanExitCodeNoChanges && exitCode != testctx.PlanExitCodeChanges {
+		return exitCode, response.err(CommandPlan)
+	}
+	return exitCode, nil
+}
+
+// Show records a show invocation, serving show-plan if options.PlanFilePath is set and show-state otherwise
+func (e *Executor) Show(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	if options.PlanFilePath != "" {
+		return e.run(CommandShowPlan, options, "")
+	}
+	return e.run(CommandShowState, options, "")
+}
+
+// Output records an output invocation
+// The scripted response holds all outputs in the format of output -json; for a key only its value is returned
+func (e *Executor) Output(t terratesting.TestingT, options *terraform.Options, key string) (string, error) {
+	stdout, err := e.run(CommandOutput, options, key)
+	if err != nil {
+		return stdout, err
+	}
+	return outputValue(stdout, key)
+}
+
+// Destroy records a destroy invocation
+func (e *Executor) Destroy(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return e.run(CommandDestroy, options, "")
+}
+
+// StateList records a state list invocation
+func (e *Executor) StateList(t terratesting.TestingT, options *terraform.Options) ([]string, error) {
+	stdout, err := e.run(CommandStateList, options, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			addresses = append(addresses, line)
+		}
+	}
+	return addresses, nil
+}
+
+// Version records a version invocation
+func (e *Executor) Version(t terratesting.TestingT, options *terraform.Options) (testctx.VersionInfo, error) {
+	stdout, err := e.run(CommandVersion, options, "")
+	if err != nil {
+		return testctx.VersionInfo{}, err
+	}
+	return testctx.ParseVersionJSON(stdout)
+}
+
+// PlanJSON records a plan -json invocation
+func (e *Executor) PlanJSON(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return e.run(CommandPlanJSON, options, "")
+}
+
+// ApplyJSON records an apply -json invocation
+func (e *Executor) ApplyJSON(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	return e.run(CommandApplyJSON, options, "")
+}
+
+// run records an invocation and returns its scripted stdout, or an error for a non-zero exit code
+func (e *Executor) run(command string, options *terraform.Options, key string) (string, error) {
+	response := e.record(command, options, key)
+	if response.exitCode() != 0 {
+		return response.Stdout, response.err(command)
+	}
+	return response.Stdout, nil
+}
+
+// record appends a call and returns the next scripted response of the command
+func (e *Executor) record(command string, options *terraform.Options, key string) Response {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	call := Call{Command: command, Key: key}
+	if options != nil {
+		call.Dir = options.TerraformDir
+		call.PlanFile = options.PlanFilePath
+		call.Vars = options.Vars
+		call.VarFiles = options.VarFiles
+		call.EnvVars = options.EnvVars
+	}
+	e.calls = append(e.calls, call)
+
+	responses := e.responses[command]
+	switch len(responses) {
+	case 0:
+		return defaultResponses[command]
+	case 1:
+		return responses[0]
+	default:
+		e.responses[command] = responses[1:]
+		return responses[0]
+	}
+}
+
+// exitCode returns the exit code of a response, where a response with an error but no exit code exits with 1
+func (r Response) exitCode() int {
+	if r.ExitCode == 0 && r.Error != "" {
+		return 1
+	}
+	return r.ExitCode
+}
+
+// err returns the error of a failing response
+func (r Response) err(command string) error {
+	if r.Error != "" {
+		return fmt.Errorf("%s failed with exit code %d: %s", command, r.exitCode(), r.Error)
+	}
+	return fmt.Errorf("%s failed with exit code %d", command, r.exitCode())
+}
+
+// outputValue extracts the value of a single output from the JSON of all outputs, or returns it whole for an empty key
+func outputValue(outputsJSON, key string) (string, error) {
+	if key == "" {
+		return outputsJSON, nil
+	}
+
+	var outputs map[string]struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(outputsJSON), &outputs); err != nil {
+		return "", fmt.Errorf("failed to parse outputs: %w", err)
+	}
+	output, exists := outputs[key]
+	if !exists {
+		return "", fmt.Errorf("output %q not found", key)
+	}
+	return string(output.Value), nil
+}
+
+// isCommand reports whether name is one of the scriptable commands
+func isCommand(name string) bool {
+	switch name {
+	case CommandInit, CommandApply, CommandPlan, CommandShowPlan, CommandShowState,
+		CommandOutput, CommandDestroy, CommandStateList, CommandVersion, CommandPlanJSON, CommandApplyJSON:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package fake
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// Recorder wraps a real executor and records the result of every command, so a run against real
+// infrastructure can be saved as a fixture directory and replayed with Load
+type Recorder struct {
+	Executor testctx.Executor
+
+	mu     sync.Mutex
+	script map[string][]Response
+}
+
+// NewRecorder creates a recorder delegating to executor
+func NewRecorder(executor testctx.Executor) *Recorder {
+	return &Recorder{Executor: executor, script: map[string][]Response{}}
+}
+
+// Save writes the recorded responses to dir/script.json
+func (r *Recorder) Save(dir string) error {
+	r.mu.Lock()
+	content, err := json.MarshalIndent(r.script, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ScriptFile), content, 0644)
+}
+
+// Init runs and records init
+func (r *Recorder) Init(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	stdout, err := r.Executor.Init(t, options)
+	r.record(CommandInit, stdout, 0, err)
+	return stdout, err
+}
+
+// Apply runs and records apply
+func (r *Recorder) Apply(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	stdout, err := r.Executor.Apply(t, options)
+	r.record(CommandApply, stdout, 0, err)
+	return stdout, err
+}
+
+// Plan runs and records plan with its exit code
+func (r *Recorder) Plan(t terratesting.TestingT, options *terraform.Options) (int, error) {
+	exitCode, err := r.Executor.Plan(t, options)
+	r.record(CommandPlan, "", exitCode, err)
+	return exitCode, err
+}
+
+// Show runs and records show as show-plan or show-state
+func (r *Recorder) Show(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	stdout, err := r.Executor.Show(t, options)
+	if options.PlanFilePath != "" {
+		r.record(CommandShowPlan, stdout, 0, err)
+	} else {
+		r.record(CommandShowState, stdout, 0, err)
+	}
+	return stdout, err
+}
+
+// Output reads and records all outputs, and returns the requested one
+func (r *Recorder) Output(t terratesting.TestingT, options *terraform.Options, key string) (string, error) {
+	stdout, err := r.Executor.Output(t, options, "")
+	r.record(CommandOutput, stdout, 0, err)
+	if err != nil {
+		return stdout, err
+	}
+	return outputValue(stdout, key)
+}
+
+// Destroy runs and records destroy
+func (r *Recorder) Destroy(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	stdout, err := r.Executor.Destroy(t, options)
+	r.record(CommandDestroy, stdout, 0, err)
+	return stdout, err
+}
+
+// StateList runs and records state list
+func (r *Recorder) StateList(t terratesting.TestingT, options *terraform.Options) ([]string, error) {
+	addresses, err := r.Executor.StateList(t, options)
+	r.record(CommandStateList, strings.Join(addresses, "\n"), 0, err)
+	return addresses, err
+}
+
+// Version runs and records version
+func (r *Recorder) Version(t terratesting.TestingT, options *terraform.Options) (testctx.VersionInfo, error) {
+	info, err := r.Executor.Version(t, options)
+	stdout, _ := json.Marshal(map[string]interface{}{
+		"terraform_version":   info.Version,
+		"platform":            info.Platform,
+		"provider_selections": info.ProviderSelections,
+	})
+	r.record(CommandVersion, string(stdout), 0, err)
+	return info, err
+}
+
+// PlanJSON runs and records plan -json
+func (r *Recorder) PlanJSON(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	stdout, err := r.Executor.PlanJSON(t, options)
+	r.record(CommandPlanJSON, stdout, 0, err)
+	return stdout, err
+}
+
+// ApplyJSON runs and records apply -json
+func (r *Recorder) ApplyJSON(t terratesting.TestingT, options *terraform.Options) (string, error) {
+	stdout, err := r.Executor.ApplyJSON(t, options)
+	r.record(CommandApplyJSON, stdout, 0, err)
+	return stdout, err
+}
+
+// record appends the result of a command to the script
+func (r *Recorder) record(command, stdout string, exitCode int, err error) {
+	response := Response{Stdout: stdout, ExitCode: exitCode}
+	if err != nil {
+		response.Error = err.Error()
+		if response.ExitCode == 0 || response.ExitCode == testctx.PlanExitCodeChanges {
+			response.ExitCode = 1
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.script[command] = append(r.script[command], response)
+}
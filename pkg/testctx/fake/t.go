@@ -0,0 +1,146 @@
+package fake
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// T is a testing.TB that records failures and skips instead of reporting them, for testing failure paths
+// It delegates logging, temporary directories and the test name to the wrapped test
+type T struct {
+	testing.TB
+
+	mu       sync.Mutex
+	failed   bool
+	skipped  bool
+	messages []string
+	cleanups []func()
+}
+
+// Run calls fn with a recording T in a separate goroutine, runs the cleanups it registered and returns it
+// FailNow, Fatal and Skip stop fn like they stop a real test, without failing or skipping the wrapped test
+func Run(t testing.TB, fn func(t *T)) *T {
+	recorder := &T{TB: t}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer recorder.runCleanups()
+		fn(recorder)
+	}()
+	<-done
+
+	return recorder
+}
+
+// Failed reports whether the function failed
+func (t *T) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed
+}
+
+// Skipped reports whether the function was skipped
+func (t *T) Skipped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.skipped
+}
+
+// Messages returns the recorded error, fatal and skip messages in order
+func (t *T) Messages() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.messages...)
+}
+
+func (t *T) Fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed = true
+}
+
+func (t *T) FailNow() {
+	t.Fail()
+	runtime.Goexit()
+}
+
+func (t *T) Error(args ...interface{}) {
+	t.fail(fmt.Sprintln(args...))
+}
+
+func (t *T) Errorf(format string, args ...interface{}) {
+	t.fail(fmt.Sprintf(format, args...))
+}
+
+func (t *T) Fatal(args ...interface{}) {
+	t.fail(fmt.Sprintln(args...))
+	runtime.Goexit()
+}
+
+func (t *T) Fatalf(format string, args ...interface{}) {
+	t.fail(fmt.Sprintf(format, args...))
+	runtime.Goexit()
+}
+
+func (t *T) Skip(args ...interface{}) {
+	t.skip(fmt.Sprintln(args...))
+}
+
+func (t *T) Skipf(format string, args ...interface{}) {
+	t.skip(fmt.Sprintf(format, args...))
+}
+
+func (t *T) SkipNow() {
+	t.skip("")
+}
+
+// Cleanup registers a function to run when Run returns, in last added, first called order
+func (t *T) Cleanup(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanups = append(t.cleanups, fn)
+}
+
+// fail records a failure message
+func (t *T) fail(message string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed = true
+	t.messages = append(t.messages, message)
+}
+
+// skip records a skip and stops the function
+func (t *T) skip(message string) {
+	t.mu.Lock()
+	t.skipped = true
+	if message != "" {
+		t.messages = append(t.messages, message)
+	}
+	t.mu.Unlock()
+	runtime.Goexit()
+}
+
+// runCleanups runs the registered cleanups, which may themselves fail the test
+func (t *T) runCleanups() {
+	for {
+		t.mu.Lock()
+		if len(t.cleanups) == 0 {
+			t.mu.Unlock()
+			return
+		}
+		fn := t.cleanups[len(t.cleanups)-1]
+		t.cleanups = t.cleanups[:len(t.cleanups)-1]
+		t.mu.Unlock()
+
+		// Run each cleanup in its own goroutine so a FailNow in one does not skip the others
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			fn()
+		}()
+		<-done
+	}
+}
@@ -0,0 +1,252 @@
+package testctx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sharedFixture is an example that is applied once and shared by every test that requests it
+type sharedFixture struct {
+	once sync.Once
+	ctx  TestContext
+	err  error
+	// applied is set once apply has been attempted, so partially created resources are destroyed as well
+	applied bool
+	// tempDirs are removed after the fixture is destroyed
+	tempDirs []string
+}
+
+// sharedFixtures is the registry of fixtures created by this go test process
+var sharedFixtures = struct {
+	sync.Mutex
+	managed  bool
+	fixtures map[string]*sharedFixture
+	order    []string
+}{fixtures: map[string]*sharedFixture{}}
+
+// RunWithSharedFixtures runs the tests of a package and destroys all shared fixtures once they have finished
+// Call it from TestMain to use SharedFixture:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(testctx.RunWithSharedFixtures(m))
+//	}
+func RunWithSharedFixtures(m *testing.M) int {
+	destroy := ManageSharedFixtures()
+
+	code := m.Run()
+
+	if err := destroy(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to destroy shared fixtures: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	return code
+}
+
+// ManageSharedFixtures allows SharedFixture to create fixtures until the returned function is called, which destroys
+// them like RunWithSharedFixtures. Use it in test harnesses that do not run the tests with testing.M
+func ManageSharedFixtures() func() error {
+	sharedFixtures.Lock()
+	sharedFixtures.managed = true
+	sharedFixtures.Unlock()
+
+	return func() error {
+		err := DestroySharedFixtures()
+		sharedFixtures.Lock()
+		sharedFixtures.managed = false
+		sharedFixtures.Unlock()
+		return err
+	}
+}
+
+// SharedFixture returns the test context of an example that is applied at most once per go test process
+// Every test requesting the same example and config name gets the same context, and the idempotency check runs once
+// Fixtures run in an isolated workspace unless config.Workspace is WorkspaceInPlace
+// The fixture is destroyed by RunWithSharedFixtures after all tests have finished, so TestMain must call it
+// The test is skipped if the example was not selected by tftest run (see ExampleSelected)
+// The settings of the example's tftest.yaml manifest are added to the config (see ApplyManifest)
+func SharedFixture(t testing.TB, examplesDir, name string, config TestConfig) TestContext {
+	t.Helper()
+
+	if config.Name == "" {
+		config.Name = name
+	}
+
+	examplePath := filepath.Join(examplesDir, name)
+	if _, err := os.Stat(examplePath); os.IsNotExist(err) {
+		t.Fatalf("Example %s not found at path %s", name, examplePath)
+	}
+	if !ExampleSelected(name) {
+		t.Skipf("Skipping example %s: not selected", name)
+	}
+	config = exampleConfig(t, examplePath, config)
+
+	key := examplePath
+	if absPath, err := filepath.Abs(examplePath); err == nil {
+		key = absPath
+	}
+	key += "#" + config.Name
+
+	sharedFixtures.Lock()
+	if !sharedFixtures.managed {
+		sharedFixtures.Unlock()
+		t.Fatalf("SharedFixture requires TestMain to call testctx.RunWithSharedFixtures (or testctx.ManageSharedFixtures), otherwise %s is never destroyed", name)
+	}
+	fixture, exists := sharedFixtures.fixtures[key]
+	if !exists {
+		fixture = &sharedFixture{}
+		sharedFixtures.fixtures[key] = fixture
+		sharedFixtures.order = append(sharedFixtures.order, key)
+	}
+	sharedFixtures.Unlock()
+
+	fixture.once.Do(func() {
+		fixture.err = fixture.setup(t, examplePath, config)
+	})
+	if fixture.err != nil {
+		t.Fatalf("Shared fixture %s failed: %v", config.Name, fixture.err)
+	}
+
+	return fixture.ctx
+}
+
+// DestroySharedFixtures destroys all shared fixtures in reverse order of creation and clears the registry
+// It is called by RunWithSharedFixtures and only needs to be called directly by custom TestMain implementations
+func DestroySharedFixtures() error {
+	sharedFixtures.Lock()
+	defer sharedFixtures.Unlock()
+
+	var errs []error
+	for i := len(sharedFixtures.order) - 1; i >= 0; i-- {
+		fixture := sharedFixtures.fixtures[sharedFixtures.order[i]]
+		if err := fixture.destroy(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	sharedFixtures.fixtures = map[string]*sharedFixture{}
+	sharedFixtures.order = nil
+	return errors.Join(errs...)
+}
+
+// setup applies the example (or plans it in plan-only mode), runs the idempotency check and compares the outputs
+// with the expected outputs of the config
+// It uses the test that first requested the fixture for logging only, failures are returned to every requesting test
+func (f *sharedFixture) setup(t testing.TB, examplePath string, config TestConfig) error {
+	start := time.Now()
+	f.ctx = Run(examplePath, config)
+
+	// Fixtures outlive the test that created them, so they are isolated from other runs of the same example by default
+	if config.Workspace != WorkspaceInPlace {
+		workspace, workspaceExample, err := CreateIsolatedWorkspaceE(examplePath)
+		if workspace != "" {
+			f.tempDirs = append(f.tempDirs, workspace)
+		}
+		if err != nil {
+			return err
+		}
+		f.ctx.WorkspacePath = workspaceExample
+		f.ctx.Terraform.TerraformDir = workspaceExample
+	}
+
+	if config.PlanOnly || PlanOnlyEnabled() {
+		planDir, err := os.MkdirTemp("", "tftest-plan-")
+		if err != nil {
+			return err
+		}
+		f.tempDirs = append(f.tempDirs, planDir)
+		f.ctx.Terraform.PlanFilePath = filepath.Join(planDir, "tfplan")
+
+		t.Logf("Planning shared fixture %s, no resources will be created", config.Name)
+		f.ctx.Plan, err = InitAndPlanE(t, f.ctx)
+		return err
+	}
+
+	t.Logf("Applying shared fixture %s", config.Name)
+	f.applied = true
+	if _, err := f.ctx.GetExecutor().Init(t, f.ctx.Terraform); err != nil {
+		return err
+	}
+	f.ctx.ResetOutputs()
+	if err := timeoutE(f.ctx, start); err != nil {
+		return err
+	}
+	if _, err := f.ctx.GetExecutor().Apply(t, f.ctx.Terraform); err != nil {
+		return err
+	}
+
+	if err := timeoutE(f.ctx, start); err != nil {
+		return err
+	}
+	if !IdempotencyEnabled() {
+		t.Log("Idempotency testing disabled via TERRATEST_IDEMPOTENCY=false")
+	} else if config.DisableIdempotency {
+		t.Logf("Idempotency testing disabled for %s", config.Name)
+	} else {
+		result, err := CheckConvergenceE(t, f.ctx, MaxApplies(config))
+		if err != nil {
+			return fmt.Errorf("idempotency test failed: %w", err)
+		}
+		if !result.Converged {
+			return fmt.Errorf("idempotency test failed: %s", result)
+		}
+	}
+
+	if err := timeoutE(f.ctx, start); err != nil {
+		return err
+	}
+	return expectedOutputsE(t, f.ctx)
+}
+
+// destroy destroys the fixture resources if it was applied and removes its temporary directories
+func (f *sharedFixture) destroy() error {
+	var err error
+	if f.applied {
+		t := &fixtureT{name: "SharedFixture/" + f.ctx.Name}
+		if _, destroyErr := f.ctx.GetExecutor().Destroy(t, f.ctx.Terraform); destroyErr != nil {
+			err = fmt.Errorf("destroy of %s failed: %w", f.ctx.Name, destroyErr)
+		}
+	}
+
+	for _, dir := range f.tempDirs {
+		os.RemoveAll(dir)
+	}
+	return err
+}
+
+// fixtureT is the testing interface used to destroy shared fixtures after all tests have finished
+type fixtureT struct {
+	name string
+}
+
+func (f *fixtureT) Fail() {}
+
+func (f *fixtureT) FailNow() {
+	panic(fmt.Sprintf("%s failed", f.name))
+}
+
+func (f *fixtureT) Fatal(args ...interface{}) {
+	panic(fmt.Sprint(args...))
+}
+
+func (f *fixtureT) Fatalf(format string, args ...interface{}) {
+	panic(fmt.Sprintf(format, args...))
+}
+
+func (f *fixtureT) Error(args ...interface{}) {
+	fmt.Fprintln(os.Stderr, args...)
+}
+
+func (f *fixtureT) Errorf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+func (f *fixtureT) Name() string {
+	return f.name
+}
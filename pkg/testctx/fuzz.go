@@ -0,0 +1,450 @@
+package testctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/fuzz"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/tfconfig"
+)
+
+// FuzzOutcome classifies how a plan handled a generated input
+type FuzzOutcome string
+
+const (
+	// FuzzAccepted means the plan succeeded
+	FuzzAccepted FuzzOutcome = "accepted"
+	// FuzzRejected means the input was rejected by a type constraint or validation block
+	FuzzRejected FuzzOutcome = "rejected"
+	// FuzzUnhelpful means the input passed validation, but the plan failed later with an unrelated error
+	FuzzUnhelpful FuzzOutcome = "unhelpful"
+	// FuzzCrashed means Terraform or a provider crashed, or the plan failed without any error diagnostic
+	FuzzCrashed FuzzOutcome = "crashed"
+)
+
+// variableErrorSummaries are the diagnostic summaries Terraform reports for inputs rejected before planning
+var variableErrorSummaries = []string{
+	"Invalid value for variable",
+	"Invalid value for input variable",
+	"Required variable not set",
+	"No value for required variable",
+}
+
+// crashMarkers identify crashes in the output of a failed plan
+var crashMarkers = []string{"panic:", "Terraform crashed", "plugin crashed", "Plugin did not respond"}
+
+// FuzzOptions controls the inputs generated by FuzzVariables
+type FuzzOptions struct {
+	// Variables limits fuzzing to the named variables of the module (default: all of them)
+	Variables []string
+	// RandomCases is the number of random inputs per variable (default 3), a negative value disables them
+	RandomCases int
+	// Seed seeds the random inputs so findings can be reproduced (default 1)
+	Seed int64
+	// LargeSize is the length of oversized strings, lists and maps (default 1000)
+	LargeSize int
+}
+
+// FuzzResult is the outcome of planning a single generated input
+type FuzzResult struct {
+	Variable string `json:"variable"`
+	// Through is the variable of the example the input was passed to the module with,
+	// empty if the input was planned against the module, or the example without a local module call, directly
+	Through string      `json:"through,omitempty"`
+	Case    string      `json:"case"`
+	Input   string      `json:"input"`
+	Outcome FuzzOutcome `json:"outcome"`
+	// Diagnostics holds the error diagnostics of the plan
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// Error is the error of a plan that failed without diagnostics
+	Error string `json:"error,omitempty"`
+}
+
+// FuzzSkip is a variable of the module that could not be fuzzed
+type FuzzSkip struct {
+	Variable string `json:"variable"`
+	Reason   string `json:"reason"`
+}
+
+// FuzzReport holds the results of fuzzing the variables of the module an example calls
+type FuzzReport struct {
+	Example string `json:"example"`
+	// Module is the directory of the fuzzed module, the example itself if it calls no local module
+	Module  string       `json:"module"`
+	Results []FuzzResult `json:"results"`
+	Skipped []FuzzSkip   `json:"skipped,omitempty"`
+}
+
+// Findings returns the results that should be fixed: crashes and unhelpful errors
+func (r FuzzReport) Findings() []FuzzResult {
+	var findings []FuzzResult
+	for _, result := range r.Results {
+		if result.Outcome == FuzzCrashed || result.Outcome == FuzzUnhelpful {
+			findings = append(findings, result)
+		}
+	}
+	return findings
+}
+
+// Count returns the number of results with the given outcome
+func (r FuzzReport) Count(outcome FuzzOutcome) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Outcome == outcome {
+			count++
+		}
+	}
+	return count
+}
+
+// String summarizes the report and lists the findings
+func (r FuzzReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d inputs, %d accepted, %d rejected, %d unhelpful, %d crashed",
+		r.Example, len(r.Results), r.Count(FuzzAccepted), r.Count(FuzzRejected), r.Count(FuzzUnhelpful), r.Count(FuzzCrashed))
+	for _, skip := range r.Skipped {
+		fmt.Fprintf(&b, "\n  SKIPPED %s: %s", skip.Variable, skip.Reason)
+	}
+	for _, finding := range r.Findings() {
+		fmt.Fprintf(&b, "\n  %s %s/%s = %s", strings.ToUpper(string(finding.Outcome)), finding.Variable, finding.Case, truncate(finding.Input, 80))
+		for _, diagnostic := range finding.Diagnostics {
+			fmt.Fprintf(&b, "\n    %s", diagnostic)
+		}
+		if finding.Error != "" {
+			fmt.Fprintf(&b, "\n    %s", finding.Error)
+		}
+	}
+	return b.String()
+}
+
+// FuzzVariables generates boundary and random inputs for the variables of the module an example calls from their
+// type constraints, defaults and validation blocks, and runs a plan for each input. Nothing is applied
+// Inputs are passed through the example variable the module variable is set to, e.g. name = var.name, and planned
+// against the module directly if the example sets it otherwise. An example without a local module call is fuzzed itself
+// Each input that crashes the plan or passes validation but fails the plan with an unrelated error fails the test
+// The test is skipped if the example's tftest.yaml manifest skips it, or skips it in plan-only mode
+func FuzzVariables(t testing.TB, examplePath string, config TestConfig, options FuzzOptions) FuzzReport {
+	m, err := LoadManifest(examplePath)
+	if err != nil {
+		t.Fatalf("Failed to load the manifest of %s: %v", examplePath, err)
+	}
+	skipExample(t, examplePath, m, true)
+
+	report, err := FuzzVariablesE(t, examplePath, config, options)
+	if err != nil {
+		t.Fatalf("Failed to fuzz variables of %s: %v", examplePath, err)
+	}
+
+	t.Log(report.String())
+	for _, finding := range report.Findings() {
+		t.Errorf("Input %s/%s of %s %s: %s", finding.Variable, finding.Case, examplePath, finding.Outcome, finding.summary())
+	}
+	return report
+}
+
+// FuzzVariablesE runs FuzzVariables and returns the report instead of failing the test on findings
+// It returns an error if the variables cannot be read or the plan with the unmodified inputs fails
+// The settings of the example's tftest.yaml manifest are added to the config (see ApplyManifest)
+func FuzzVariablesE(t terratesting.TestingT, examplePath string, config TestConfig, options FuzzOptions) (FuzzReport, error) {
+	report := FuzzReport{Example: examplePath}
+
+	m, err := LoadManifest(examplePath)
+	if err != nil {
+		return report, err
+	}
+	config = ApplyManifest(config, m)
+
+	example, err := tfconfig.LoadModule(examplePath)
+	if err != nil {
+		return report, err
+	}
+	module, call, err := moduleUnderTest(example)
+	if err != nil {
+		return report, err
+	}
+	report.Module = module.Dir
+	variables, err := fuzzedVariables(module, options.Variables)
+	if err != nil {
+		return report, err
+	}
+
+	base, err := baseInputs(examplePath, example, config)
+	if err != nil {
+		return report, err
+	}
+
+	// Generated inputs are not what the tests exercise, so they are not recorded for tftest coverage
+	ctx := newContext(examplePath, config)
+	if IsolationEnabled(config) {
+		root, workspaceExample, err := CreateIsolatedWorkspaceE(examplePath)
+		defer os.RemoveAll(root)
+		if err != nil {
+			return report, err
+		}
+		ctx.WorkspacePath = workspaceExample
+		ctx.Terraform.TerraformDir = workspaceExample
+	}
+
+	varsDir, err := os.MkdirTemp("", "tftest-fuzz-")
+	if err != nil {
+		return report, err
+	}
+	defer os.RemoveAll(varsDir)
+
+	executor := ctx.GetExecutor()
+	if _, err := executor.Init(t, ctx.Terraform); err != nil {
+		return report, fmt.Errorf("init failed: %w", err)
+	}
+
+	// The unmodified inputs must plan, otherwise every result would be caused by the example itself
+	baseline := planInputs(t, executor, ctx.Terraform, varsDir, "baseline", base)
+	if baseline.Outcome != FuzzAccepted {
+		return report, fmt.Errorf("plan with the unmodified inputs failed: %s", baseline.summary())
+	}
+
+	generation := fuzz.Options{RandomCases: options.RandomCases, Seed: options.Seed, LargeSize: options.LargeSize}
+	var direct []tfconfig.Variable
+	for _, variable := range variables {
+		input := variable.Name
+		if call != nil {
+			forwarding, ok := call.Forwards[variable.Name]
+			if !ok {
+				direct = append(direct, variable)
+				continue
+			}
+			input = forwarding
+		}
+		report.Results = append(report.Results, fuzzVariable(t, executor, ctx.Terraform, varsDir, variable, input, base, generation)...)
+	}
+	if len(direct) == 0 {
+		return report, nil
+	}
+
+	// The example sets the remaining variables itself, so their inputs are planned against the module directly
+	moduleBase, err := baseInputs(module.Dir, module, TestConfig{})
+	if err != nil {
+		return report, err
+	}
+	moduleOptions := *ctx.Terraform
+	moduleOptions.TerraformDir = filepath.Join(ctx.Terraform.TerraformDir, call.Source)
+	moduleOptions.Vars = nil
+	moduleOptions.VarFiles = nil
+
+	reason := ""
+	if _, err := executor.Init(t, &moduleOptions); err != nil {
+		reason = fmt.Sprintf("not set by the example as a variable, and init of the module failed: %v", err)
+	} else if baseline := planInputs(t, executor, &moduleOptions, varsDir, "module-baseline", moduleBase); baseline.Outcome != FuzzAccepted {
+		reason = fmt.Sprintf("not set by the example as a variable, and the module cannot be planned on its own: %s", baseline.summary())
+	}
+	for _, variable := range direct {
+		if reason != "" {
+			report.Skipped = append(report.Skipped, FuzzSkip{Variable: variable.Name, Reason: reason})
+			continue
+		}
+		report.Results = append(report.Results, fuzzVariable(t, executor, &moduleOptions, varsDir, variable, "", moduleBase, generation)...)
+	}
+	return report, nil
+}
+
+// moduleUnderTest returns the first module the example calls with a local source, e.g. ../../, and its call
+// It returns the example itself and a nil call if the example calls no local module
+func moduleUnderTest(example *tfconfig.Module) (*tfconfig.Module, *tfconfig.ModuleCall, error) {
+	for i, call := range example.ModuleCalls {
+		if !tfconfig.IsLocalSource(call.Source) {
+			continue
+		}
+		module, err := tfconfig.LoadModule(filepath.Join(example.Dir, call.Source))
+		if err != nil {
+			return nil, nil, fmt.Errorf("module %s: %w", call.Name, err)
+		}
+		return module, &example.ModuleCalls[i], nil
+	}
+	return example, nil, nil
+}
+
+// fuzzVariable plans each generated input of a variable combined with base
+// The inputs are set as the variable named through, or as the variable itself if through is empty
+func fuzzVariable(t terratesting.TestingT, executor Executor, options *terraform.Options, varsDir string, variable tfconfig.Variable, through string, base map[string]interface{}, generation fuzz.Options) []FuzzResult {
+	input := through
+	if input == "" {
+		input = variable.Name
+	}
+
+	var results []FuzzResult
+	for i, c := range fuzz.Cases(variable, generation) {
+		inputs := make(map[string]interface{}, len(base)+1)
+		for name, value := range base {
+			inputs[name] = value
+		}
+		inputs[input] = c.Value
+
+		result := planInputs(t, executor, options, varsDir, fmt.Sprintf("%s-%d", variable.Name, i), inputs)
+		result.Variable = variable.Name
+		result.Through = through
+		result.Case = c.Name
+		result.Input = encodeInput(c.Value)
+		results = append(results, result)
+	}
+	return results
+}
+
+// fuzzedVariables returns the variables of the module to fuzz, all of them if names is empty
+func fuzzedVariables(module *tfconfig.Module, names []string) ([]tfconfig.Variable, error) {
+	if len(names) == 0 {
+		if len(module.Variables) == 0 {
+			return nil, fmt.Errorf("no variables declared in %s", module.Dir)
+		}
+		return module.Variables, nil
+	}
+
+	variables := make([]tfconfig.Variable, 0, len(names))
+	for _, name := range names {
+		variable, ok := module.Variable(name)
+		if !ok {
+			return nil, fmt.Errorf("variable %q is not declared in %s", name, module.Dir)
+		}
+		variables = append(variables, variable)
+	}
+	return variables, nil
+}
+
+// baseInputs returns the inputs every generated input is combined with: the config's ExtraVars and
+// a placeholder for required variables that are neither set there nor in terraform.tfvars or the config's VarFiles
+func baseInputs(examplePath string, module *tfconfig.Module, config TestConfig) (map[string]interface{}, error) {
+	inputs := make(map[string]interface{}, len(config.ExtraVars))
+	for name, value := range config.ExtraVars {
+		inputs[name] = value
+	}
+
+	tfvars := map[string]bool{}
+	paths := []string{filepath.Join(examplePath, "terraform.tfvars")}
+	for _, file := range config.VarFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(examplePath, file)
+		}
+		paths = append(paths, file)
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		names, err := tfconfig.VarsFileNames(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			tfvars[name] = true
+		}
+	}
+
+	for _, variable := range module.Variables {
+		if _, ok := inputs[variable.Name]; ok || tfvars[variable.Name] || !variable.Required() {
+			continue
+		}
+		inputs[variable.Name] = fuzz.Placeholder(variable.Type)
+	}
+	return inputs, nil
+}
+
+// planInputs writes inputs to a .tfvars.json file, plans with it and classifies the result
+// The inputs replace the vars of the context, since -var flags would take precedence over the file
+func planInputs(t terratesting.TestingT, executor Executor, base *terraform.Options, varsDir, name string, inputs map[string]interface{}) FuzzResult {
+	content, err := json.MarshalIndent(inputs, "", "  ")
+	if err != nil {
+		return FuzzResult{Outcome: FuzzCrashed, Error: err.Error()}
+	}
+	path := filepath.Join(varsDir, unsafeFileChars.ReplaceAllString(name, "_")+".tfvars.json")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return FuzzResult{Outcome: FuzzCrashed, Error: err.Error()}
+	}
+
+	options := *base
+	options.Vars = nil
+	options.VarFiles = append(append([]string{}, base.VarFiles...), path)
+
+	output, err := executor.PlanJSON(t, &options)
+	return classifyPlan(output, err)
+}
+
+// classifyPlan determines the outcome of a plan from its -json output and error
+func classifyPlan(output string, err error) FuzzResult {
+	var errors []Diagnostic
+	for _, diagnostic := range ParseDiagnostics(output) {
+		if diagnostic.Severity == SeverityError {
+			errors = append(errors, diagnostic)
+		}
+	}
+	result := FuzzResult{Diagnostics: errors}
+
+	text := output
+	if err != nil {
+		text += err.Error()
+	}
+	for _, marker := range crashMarkers {
+		if strings.Contains(text, marker) {
+			result.Outcome = FuzzCrashed
+			if err != nil {
+				result.Error = err.Error()
+			}
+			return result
+		}
+	}
+
+	switch {
+	case err == nil:
+		result.Outcome = FuzzAccepted
+	case len(errors) == 0:
+		result.Outcome = FuzzCrashed
+		result.Error = err.Error()
+	case rejectedByVariable(errors):
+		result.Outcome = FuzzRejected
+	default:
+		result.Outcome = FuzzUnhelpful
+	}
+	return result
+}
+
+// rejectedByVariable reports whether a diagnostic rejects an input variable
+func rejectedByVariable(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		for _, summary := range variableErrorSummaries {
+			if diagnostic.Summary == summary {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// summary returns the first diagnostic or the error of a result
+func (r FuzzResult) summary() string {
+	if len(r.Diagnostics) > 0 {
+		return r.Diagnostics[0].String()
+	}
+	return r.Error
+}
+
+// encodeInput formats a generated input as JSON for reports
+func encodeInput(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(content)
+}
+
+// truncate shortens s to at most n characters
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
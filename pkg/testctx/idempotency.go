@@ -0,0 +1,396 @@
+package testctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/glob"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// Exit codes returned by terraform plan -detailed-exitcode
+const (
+	PlanExitCodeNoChanges = 0
+	PlanExitCodeError     = 1
+	PlanExitCodeChanges   = 2
+)
+
+// AttributeDiff describes a single attribute that differs between the current state and the plan
+type AttributeDiff struct {
+	Path   string
+	Before interface{}
+	After  interface{}
+	// Unknown is true when the new value will only be known after apply
+	Unknown bool
+	// Sensitive is true when the value is marked sensitive and must not be printed
+	Sensitive bool
+}
+
+// ResourceDiff describes a resource (or output) that a plan would change
+type ResourceDiff struct {
+	Address    string
+	Actions    []string
+	Attributes []AttributeDiff
+}
+
+// PlanDiff is the list of changes a plan would make
+type PlanDiff struct {
+	Resources []ResourceDiff
+	// Ignored holds the changes that matched an idempotency ignore rule
+	Ignored []ResourceDiff
+}
+
+// HasChanges returns true if the plan would change anything
+func (d PlanDiff) HasChanges() bool {
+	return len(d.Resources) > 0
+}
+
+// Addresses returns the addresses of all changed resources and outputs
+func (d PlanDiff) Addresses() []string {
+	addresses := make([]string, 0, len(d.Resources))
+	for _, resource := range d.Resources {
+		addresses = append(addresses, resource.Address)
+	}
+	return addresses
+}
+
+// String returns a readable report of the changed addresses and their differing attributes
+func (d PlanDiff) String() string {
+	if !d.HasChanges() {
+		return "No changes"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Terraform plan would change %d resource(s):\n", len(d.Resources))
+	for _, resource := range d.Resources {
+		fmt.Fprintf(&b, "  %s (%s)\n", resource.Address, strings.Join(resource.Actions, ", "))
+		for _, attribute := range resource.Attributes {
+			fmt.Fprintf(&b, "      %s: %s => %s\n", attribute.Path,
+				formatDiffValue(attribute.Before, attribute.Sensitive, false),
+				formatDiffValue(attribute.After, attribute.Sensitive, attribute.Unknown))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// FilterIgnored moves every change matching one of the ignore rules from Resources to Ignored
+// A resource stays in Resources if any of its differing attributes is not covered by a matching rule
+func (d PlanDiff) FilterIgnored(rules []IdempotencyIgnoreRule) PlanDiff {
+	filtered := PlanDiff{Ignored: d.Ignored}
+	for _, resource := range d.Resources {
+		kept, ignored := filterResource(resource, rules)
+		if kept != nil {
+			filtered.Resources = append(filtered.Resources, *kept)
+		}
+		if ignored != nil {
+			filtered.Ignored = append(filtered.Ignored, *ignored)
+		}
+	}
+	return filtered
+}
+
+// IgnoredString returns a readable report of the ignored changes
+func (d PlanDiff) IgnoredString() string {
+	return PlanDiff{Resources: d.Ignored}.String()
+}
+
+// DiffPlan extracts every resource and output change from a parsed plan
+// No-op and read actions are ignored. Attribute level differences are reported for updates and replacements
+func DiffPlan(planStruct *terraform.PlanStruct) PlanDiff {
+	var diff PlanDiff
+	if planStruct == nil {
+		return diff
+	}
+
+	for _, change := range planStruct.RawPlan.ResourceChanges {
+		if change.Change == nil || change.Change.Actions.NoOp() || change.Change.Actions.Read() {
+			continue
+		}
+		diff.Resources = append(diff.Resources, diffChange(change.Address, change.Change))
+	}
+
+	outputNames := make([]string, 0, len(planStruct.RawPlan.OutputChanges))
+	for name := range planStruct.RawPlan.OutputChanges {
+		outputNames = append(outputNames, name)
+	}
+	sort.Strings(outputNames)
+	for _, name := range outputNames {
+		change := planStruct.RawPlan.OutputChanges[name]
+		if change == nil || change.Actions.NoOp() {
+			continue
+		}
+		diff.Resources = append(diff.Resources, diffChange("output."+name, change))
+	}
+
+	return diff
+}
+
+// CheckIdempotencyE runs terraform plan -detailed-exitcode and returns the changes the plan would make
+// The JSON plan is only rendered when the exit code reports changes
+// Changes matching ctx.Config.IdempotencyIgnore are moved to the Ignored list of the returned diff
+func CheckIdempotencyE(t testing.TB, ctx TestContext) (PlanDiff, error) {
+	options := *ctx.Terraform
+	options.PlanFilePath = filepath.Join(t.TempDir(), "idempotency.tfplan")
+	// The plan is only inspected, never applied, so it does not need to hold the state lock
+	options.Lock = false
+
+	executor := ctx.GetExecutor()
+	exitCode, err := executor.Plan(t, &options)
+	if err != nil {
+		return PlanDiff{}, err
+	}
+	if exitCode == PlanExitCodeNoChanges {
+		return PlanDiff{}, nil
+	}
+
+	planJSON, err := executor.Show(t, &options)
+	if err != nil {
+		return PlanDiff{}, err
+	}
+	planStruct, err := terraform.ParsePlanJSON(planJSON)
+	if err != nil {
+		return PlanDiff{}, err
+	}
+	diff := DiffPlan(planStruct)
+	if !diff.HasChanges() {
+		t.Logf("Terraform reported changes for %s that DiffPlan did not classify (plan exit code %d)", ctx.Name, exitCode)
+	}
+	return diff.FilterIgnored(ctx.Config.IdempotencyIgnore), nil
+}
+
+// CheckIdempotency verifies that a plan after apply would not change anything
+// On failure it reports exactly which addresses would change and which attributes differ
+func CheckIdempotency(t testing.TB, ctx TestContext) bool {
+	t.Logf("Running idempotency test for %s", ctx.Name)
+
+	diff, err := CheckIdempotencyE(t, ctx)
+	if err != nil {
+		t.Errorf("Idempotency test failed for %s: %v", ctx.Name, err)
+		return false
+	}
+
+	if len(diff.Ignored) > 0 {
+		t.Logf("Ignoring expected diffs for %s: %s", ctx.Name, diff.IgnoredString())
+	}
+
+	if diff.HasChanges() {
+		t.Errorf("Idempotency test failed for %s: %s", ctx.Name, diff)
+		return false
+	}
+
+	t.Logf("Idempotency test passed for %s", ctx.Name)
+	return true
+}
+
+// filterResource splits a resource diff into the part that still counts as drift and the ignored part
+func filterResource(resource ResourceDiff, rules []IdempotencyIgnoreRule) (*ResourceDiff, *ResourceDiff) {
+	var attributePatterns []string
+	for _, rule := range rules {
+		if !glob.Match(rule.Address, resource.Address) {
+			continue
+		}
+		if len(rule.Attributes) == 0 {
+			return nil, &resource
+		}
+		attributePatterns = append(attributePatterns, rule.Attributes...)
+	}
+
+	if len(attributePatterns) == 0 || len(resource.Attributes) == 0 {
+		return &resource, nil
+	}
+
+	kept := ResourceDiff{Address: resource.Address, Actions: resource.Actions}
+	ignored := ResourceDiff{Address: resource.Address, Actions: resource.Actions}
+	for _, attribute := range resource.Attributes {
+		if matchesAny(attributePatterns, attribute.Path) {
+			ignored.Attributes = append(ignored.Attributes, attribute)
+		} else {
+			kept.Attributes = append(kept.Attributes, attribute)
+		}
+	}
+
+	switch {
+	case len(kept.Attributes) == 0:
+		return nil, &ignored
+	case len(ignored.Attributes) == 0:
+		return &kept, nil
+	default:
+		return &kept, &ignored
+	}
+}
+
+// matchesAny reports whether value matches any of the glob patterns
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if glob.Match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// diffChange builds the resource diff for a single planned change
+func diffChange(address string, change *tfjson.Change) ResourceDiff {
+	actions := make([]string, 0, len(change.Actions))
+	for _, action := range change.Actions {
+		actions = append(actions, string(action))
+	}
+
+	resource := ResourceDiff{Address: address, Actions: actions}
+	if change.Actions.Update() || change.Actions.Replace() {
+		sensitive := mergeFlags(change.BeforeSensitive, change.AfterSensitive)
+		diffValues("", change.Before, change.After, change.AfterUnknown, sensitive, &resource.Attributes)
+	}
+	return resource
+}
+
+// diffValues recursively compares two decoded JSON values and records every differing leaf attribute
+func diffValues(path string, before, after, unknown, sensitive interface{}, diffs *[]AttributeDiff) {
+	if unknown == true {
+		*diffs = append(*diffs, AttributeDiff{Path: path, Before: before, Unknown: true, Sensitive: sensitive == true})
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	unknownMap, unknownIsMap := unknown.(map[string]interface{})
+	if (beforeIsMap || before == nil) && (afterIsMap || after == nil) && (beforeIsMap || afterIsMap || unknownIsMap) {
+		keys := map[string]bool{}
+		for key := range beforeMap {
+			keys[key] = true
+		}
+		for key := range afterMap {
+			keys[key] = true
+		}
+		for key := range unknownMap {
+			keys[key] = true
+		}
+
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+
+		for _, key := range sortedKeys {
+			diffValues(joinPath(path, key), beforeMap[key], afterMap[key], childFlag(unknown, key), childFlag(sensitive, key), diffs)
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	unknownList, unknownIsList := unknown.([]interface{})
+	if (beforeIsList || before == nil) && (afterIsList || after == nil) && (beforeIsList || afterIsList || unknownIsList) {
+		length := len(beforeList)
+		if len(afterList) > length {
+			length = len(afterList)
+		}
+		if len(unknownList) > length {
+			length = len(unknownList)
+		}
+		for i := 0; i < length; i++ {
+			var beforeItem, afterItem interface{}
+			if i < len(beforeList) {
+				beforeItem = beforeList[i]
+			}
+			if i < len(afterList) {
+				afterItem = afterList[i]
+			}
+			key := strconv.Itoa(i)
+			diffValues(joinPath(path, key), beforeItem, afterItem, childFlag(unknown, key), childFlag(sensitive, key), diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*diffs = append(*diffs, AttributeDiff{Path: path, Before: before, After: after, Sensitive: sensitive == true})
+	}
+}
+
+// childFlag returns the nested value of an after_unknown or sensitive flag structure
+func childFlag(flags interface{}, key string) interface{} {
+	switch node := flags.(type) {
+	case map[string]interface{}:
+		return node[key]
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(node) {
+			return nil
+		}
+		return node[index]
+	default:
+		return nil
+	}
+}
+
+// mergeFlags combines the before and after sensitivity structures so a value sensitive on either side is masked
+func mergeFlags(before, after interface{}) interface{} {
+	if before == true || after == true {
+		return true
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap || afterIsMap {
+		merged := map[string]interface{}{}
+		for key, value := range beforeMap {
+			merged[key] = mergeFlags(value, afterMap[key])
+		}
+		for key, value := range afterMap {
+			if _, exists := merged[key]; !exists {
+				merged[key] = mergeFlags(nil, value)
+			}
+		}
+		return merged
+	}
+
+	beforeList, _ := before.([]interface{})
+	afterList, _ := after.([]interface{})
+	if beforeList != nil || afterList != nil {
+		length := len(beforeList)
+		if len(afterList) > length {
+			length = len(afterList)
+		}
+		merged := make([]interface{}, length)
+		for i := range merged {
+			merged[i] = mergeFlags(childFlag(before, strconv.Itoa(i)), childFlag(after, strconv.Itoa(i)))
+		}
+		return merged
+	}
+
+	return nil
+}
+
+// joinPath appends a segment to a dotted attribute path
+func joinPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+// formatDiffValue renders a single attribute value for the idempotency report
+func formatDiffValue(value interface{}, sensitive, unknown bool) string {
+	switch {
+	case unknown:
+		return "(known after apply)"
+	case sensitive:
+		return "(sensitive value)"
+	case value == nil:
+		return "null"
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
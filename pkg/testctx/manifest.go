@@ -0,0 +1,204 @@
+package testctx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
+)
+
+// Manifest is the metadata of an example declared in its optional tftest.yaml file, such as tags, a timeout,
+// vars, var files, env vars, skip reasons, idempotency settings, expected outputs and dependencies
+type Manifest = manifest.Manifest
+
+// LoadManifest reads the tftest.yaml manifest of an example directory
+// Examples without a manifest return an empty manifest
+func LoadManifest(examplePath string) (Manifest, error) {
+	return manifest.Load(examplePath)
+}
+
+// ApplyManifest returns the config with the settings of an example manifest added
+// Settings made in Go take precedence: vars, env vars and expected outputs are only added when the config does not
+// set them, and the timeout and max applies only when they are zero. Applying a manifest twice changes nothing
+func ApplyManifest(config TestConfig, m Manifest) TestConfig {
+	config.ExtraVars = mergeMissing(config.ExtraVars, m.Vars)
+	config.ExpectedOutputs = mergeMissing(config.ExpectedOutputs, m.ExpectedOutputs)
+
+	if len(m.Env) > 0 {
+		env := make(map[string]string, len(config.EnvVars)+len(m.Env))
+		for name, value := range m.Env {
+			env[name] = value
+		}
+		for name, value := range config.EnvVars {
+			env[name] = value
+		}
+		config.EnvVars = env
+	}
+
+	varFiles := make([]string, 0, len(m.VarFiles)+len(config.VarFiles))
+	for _, file := range append(append([]string{}, m.VarFiles...), config.VarFiles...) {
+		if !containsString(varFiles, file) {
+			varFiles = append(varFiles, file)
+		}
+	}
+	if len(varFiles) > 0 {
+		config.VarFiles = varFiles
+	}
+
+	if config.Timeout == 0 {
+		config.Timeout = m.Timeout
+	}
+	if config.MaxApplies == 0 {
+		config.MaxApplies = m.Idempotency.MaxApplies
+	}
+	if m.Idempotency.Enabled != nil && !*m.Idempotency.Enabled {
+		config.DisableIdempotency = true
+	}
+	for _, rule := range m.Idempotency.Ignore {
+		ignore := IdempotencyIgnoreRule{Address: rule.Address, Attributes: rule.Attributes}
+		if !containsRule(config.IdempotencyIgnore, ignore) {
+			config.IdempotencyIgnore = append(config.IdempotencyIgnore, ignore)
+		}
+	}
+	return config
+}
+
+// exampleConfig applies the manifest of an example to the config of a runner function
+// The test is skipped if the manifest declares a reason to skip the example in this run
+// In version matrix runs the name is suffixed with the Terraform version (see VersionedName)
+func exampleConfig(t testing.TB, examplePath string, config TestConfig) TestConfig {
+	config.Name = VersionedName(config.Name)
+	m, err := LoadManifest(examplePath)
+	if err != nil {
+		t.Fatalf("Failed to load the manifest of %s: %v", config.Name, err)
+	}
+	skipExample(t, config.Name, m, config.PlanOnly || PlanOnlyEnabled())
+	return ApplyManifest(config, m)
+}
+
+// skipExample skips the test if the manifest declares a reason to skip the example
+// planOnly is set for runs that plan the example without applying it
+func skipExample(t testing.TB, name string, m Manifest, planOnly bool) {
+	if m.Skip.Reason != "" {
+		t.Skipf("Skipping %s: %s", name, m.Skip.Reason)
+	}
+	if m.Skip.PlanOnly != "" && planOnly {
+		t.Skipf("Skipping %s in plan-only mode: %s", name, m.Skip.PlanOnly)
+	}
+}
+
+// checkTimeout fails the test if the example has run longer than its config allows
+func checkTimeout(t testing.TB, ctx TestContext, start time.Time) {
+	if err := timeoutE(ctx, start); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// timeoutE returns an error if the example has run longer than its config allows
+func timeoutE(ctx TestContext, start time.Time) error {
+	if ctx.Config.Timeout > 0 && time.Since(start) > ctx.Config.Timeout {
+		return fmt.Errorf("%s exceeded its timeout of %s", ctx.Name, ctx.Config.Timeout)
+	}
+	return nil
+}
+
+// checkExpectedOutputs fails the test for each output that does not match the expected outputs of its config
+func checkExpectedOutputs(t testing.TB, ctx TestContext) {
+	for _, err := range expectedOutputErrors(t, ctx) {
+		t.Error(err)
+	}
+}
+
+// expectedOutputsE returns an error listing the outputs that do not match the expected outputs of the config
+func expectedOutputsE(t testing.TB, ctx TestContext) error {
+	return errors.Join(expectedOutputErrors(t, ctx)...)
+}
+
+// expectedOutputErrors compares the outputs of an example with the expected outputs of its config
+// Values are compared by their JSON encoding, so numbers match regardless of their Go type
+func expectedOutputErrors(t testing.TB, ctx TestContext) []error {
+	names := make([]string, 0, len(ctx.Config.ExpectedOutputs))
+	for name := range ctx.Config.ExpectedOutputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		var actual interface{}
+		if err := ctx.DecodeOutputE(t, name, &actual); err != nil {
+			errs = append(errs, fmt.Errorf("failed to read expected output %s of %s: %w", name, ctx.Name, err))
+			continue
+		}
+		expected, err := normalizeJSON(ctx.Config.ExpectedOutputs[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid expected output %s of %s: %w", name, ctx.Name, err))
+			continue
+		}
+		if !reflect.DeepEqual(expected, actual) {
+			errs = append(errs, fmt.Errorf("output %s of %s does not match the expected output:\n  expected: %s\n  actual:   %s",
+				name, ctx.Name, encodeJSON(expected), encodeJSON(actual)))
+		}
+	}
+	return errs
+}
+
+// mergeMissing returns a copy of values with the entries of defaults it does not set
+func mergeMissing(values, defaults map[string]interface{}) map[string]interface{} {
+	if len(defaults) == 0 {
+		return values
+	}
+	merged := make(map[string]interface{}, len(values)+len(defaults))
+	for name, value := range defaults {
+		merged[name] = value
+	}
+	for name, value := range values {
+		merged[name] = value
+	}
+	return merged
+}
+
+// normalizeJSON converts a value to the types encoding/json decodes it into
+func normalizeJSON(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(content, &normalized)
+	return normalized, err
+}
+
+// encodeJSON encodes a value for failure messages
+func encodeJSON(value interface{}) string {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+	return string(content)
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsRule reports whether rules contains an equal rule
+func containsRule(rules []IdempotencyIgnoreRule, rule IdempotencyIgnoreRule) bool {
+	for _, candidate := range rules {
+		if reflect.DeepEqual(candidate, rule) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package testctx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// outputCache holds the outputs of a context after the first read, so repeated reads do not run terraform output
+// It is shared by all copies of a TestContext and reset after every apply made by the framework
+type outputCache struct {
+	mu     sync.Mutex
+	values map[string]json.RawMessage
+}
+
+// newOutputCache creates an empty output cache
+func newOutputCache() *outputCache {
+	return &outputCache{}
+}
+
+// OutputsE returns the JSON values of all outputs, keyed by output name
+// The outputs are read with terraform output -json once and cached on the context until ResetOutputs is called
+// Reading all outputs does not mark them as asserted for tftest coverage, see RecordOutputs
+func (ctx TestContext) OutputsE(t testing.TB) (map[string]json.RawMessage, error) {
+	if ctx.outputs == nil {
+		return ctx.readOutputs(t)
+	}
+
+	ctx.outputs.mu.Lock()
+	defer ctx.outputs.mu.Unlock()
+	if ctx.outputs.values == nil {
+		values, err := ctx.readOutputs(t)
+		if err != nil {
+			return nil, err
+		}
+		ctx.outputs.values = values
+	}
+	return ctx.outputs.values, nil
+}
+
+// ResetOutputs drops the cached outputs, so the next read runs terraform output again
+// Call it after applying changes to the context outside of the framework
+func (ctx TestContext) ResetOutputs() {
+	if ctx.outputs == nil {
+		return
+	}
+	ctx.outputs.mu.Lock()
+	defer ctx.outputs.mu.Unlock()
+	ctx.outputs.values = nil
+}
+
+// OutputE returns the JSON value of a single output
+func (ctx TestContext) OutputE(t testing.TB, name string) (json.RawMessage, error) {
+	outputs, err := ctx.OutputsE(t)
+	if err != nil {
+		return nil, err
+	}
+	value, exists := outputs[name]
+	if !exists {
+		return nil, ctx.missingOutputError(name, outputs)
+	}
+	recordOutputs(ctx.ExamplePath, name)
+	return value, nil
+}
+
+// DecodeOutput decodes the value of an output into v, which is typically a pointer to a struct, map or slice
+// Object attributes map to struct fields like encoding/json, using json tags
+// The test fails with the output name and field path if the output is missing or does not match the type of v
+func (ctx TestContext) DecodeOutput(t testing.TB, name string, v interface{}) {
+	if err := ctx.DecodeOutputE(t, name, v); err != nil {
+		t.Fatalf("Failed to decode output of %s: %v", ctx.Name, err)
+	}
+}
+
+// DecodeOutputE decodes the value of an output into v and returns an error instead of failing the test
+func (ctx TestContext) DecodeOutputE(t testing.TB, name string, v interface{}) error {
+	value, err := ctx.OutputE(t, name)
+	if err != nil {
+		return err
+	}
+	return decodeOutputValue(name, value, v)
+}
+
+// DecodeAllOutputs decodes all outputs into the fields of the struct v points to
+// Fields are mapped to outputs by their output tag, e.g. `output:"json_data"`, or by their name matched
+// case-insensitively or converted to snake_case, e.g. BucketName matches bucket_name
+// Fields tagged `output:"-"` are skipped and fields tagged `output:"name,optional"` may be missing
+// The test fails with the output name and field path if an output is missing or does not match its field type
+func (ctx TestContext) DecodeAllOutputs(t testing.TB, v interface{}) {
+	if err := ctx.DecodeAllOutputsE(t, v); err != nil {
+		t.Fatalf("Failed to decode outputs of %s: %v", ctx.Name, err)
+	}
+}
+
+// DecodeAllOutputsE decodes all outputs into the struct v points to and returns an error instead of failing the test
+func (ctx TestContext) DecodeAllOutputsE(t testing.TB, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("outputs can only be decoded into a pointer to a struct, got %T", v)
+	}
+
+	outputs, err := ctx.OutputsE(t)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	structValue := target.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, optional := parseOutputTag(field)
+		if name == "-" {
+			continue
+		}
+
+		outputName, value, exists := lookupOutput(outputs, name)
+		if !exists {
+			if !optional {
+				errs = append(errs, fmt.Errorf("output %q for field %s not found, available outputs: %s",
+					name, field.Name, outputNames(outputs)))
+			}
+			continue
+		}
+
+		recordOutputs(ctx.ExamplePath, outputName)
+		if err := decodeOutputValue(name, value, structValue.Field(i).Addr().Interface()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readOutputs runs terraform output -json and returns the value of each output
+// In plan-only mode nothing was applied, so the outputs known before apply are read from the plan instead
+func (ctx TestContext) readOutputs(t testing.TB) (map[string]json.RawMessage, error) {
+	if ctx.Plan != nil {
+		return planOutputs(ctx.Plan)
+	}
+
+	output, err := ctx.GetExecutor().Output(t, ctx.Terraform, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outputs: %w", err)
+	}
+
+	var raw map[string]struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(output), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse outputs: %w", err)
+	}
+
+	values := make(map[string]json.RawMessage, len(raw))
+	for name, output := range raw {
+		values[name] = output.Value
+	}
+	return values, nil
+}
+
+// planOutputs returns the values of the outputs of a plan that are known before apply
+func planOutputs(plan *terraform.PlanStruct) (map[string]json.RawMessage, error) {
+	values := map[string]json.RawMessage{}
+	if plan.RawPlan.PlannedValues == nil {
+		return values, nil
+	}
+	for name, output := range plan.RawPlan.PlannedValues.Outputs {
+		if change := plan.RawPlan.OutputChanges[name]; change != nil && change.AfterUnknown != nil && change.AfterUnknown != false {
+			continue
+		}
+		value, err := json.Marshal(output.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode planned output %s: %w", name, err)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// missingOutputError reports an output that was not found, listing the available outputs
+func (ctx TestContext) missingOutputError(name string, outputs map[string]json.RawMessage) error {
+	if ctx.Plan != nil {
+		return fmt.Errorf("output %q is not known before apply in plan-only mode, known outputs: %s", name, outputNames(outputs))
+	}
+	return fmt.Errorf("output %q not found, available outputs: %s", name, outputNames(outputs))
+}
+
+// decodeOutputValue decodes the JSON value of an output, naming the output and field path on errors
+func decodeOutputValue(name string, value json.RawMessage, v interface{}) error {
+	err := json.Unmarshal(value, v)
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field != "" {
+			return fmt.Errorf("output %q: field %s: cannot decode %s into %s", name, typeErr.Field, typeErr.Value, typeErr.Type)
+		}
+		return fmt.Errorf("output %q: cannot decode %s into %s", name, typeErr.Value, typeErr.Type)
+	}
+	return fmt.Errorf("output %q: %w", name, err)
+}
+
+// parseOutputTag returns the output name of a struct field and whether the output is optional
+func parseOutputTag(field reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("output"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, options == "optional"
+}
+
+// lookupOutput finds an output by exact name, falling back to a case-insensitive match and to the snake_case form
+// of a CamelCase name
+func lookupOutput(outputs map[string]json.RawMessage, name string) (string, json.RawMessage, bool) {
+	if value, exists := outputs[name]; exists {
+		return name, value, true
+	}
+	for outputName, value := range outputs {
+		if strings.EqualFold(outputName, name) {
+			return outputName, value, true
+		}
+	}
+	if snakeName := snakeCase(name); snakeName != name {
+		if value, exists := outputs[snakeName]; exists {
+			return snakeName, value, true
+		}
+	}
+	return "", nil, false
+}
+
+// snakeCase converts a CamelCase name to snake_case, keeping acronyms together, e.g. JSONData becomes json_data
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var result strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				result.WriteRune('_')
+			}
+		}
+		result.WriteRune(unicode.ToLower(r))
+	}
+	return result.String()
+}
+
+// outputNames lists the output names in alphabetical order
+func outputNames(outputs map[string]json.RawMessage) string {
+	if len(outputs) == 0 {
+		return "none"
+	}
+	return strings.Join(outputNameList(outputs), ", ")
+}
+
+// outputNameList returns the output names in alphabetical order
+func outputNameList(outputs map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
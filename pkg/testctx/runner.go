@@ -4,65 +4,224 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
 	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
 )
 
 // InitTerraform creates terraform options for the given path and config
 func InitTerraform(path string, config TestConfig) *terraform.Options {
 	return &terraform.Options{
-		TerraformDir: path,
-		Vars:         config.ExtraVars,
+		TerraformBinary: ConfiguredBinary(config),
+		TerraformDir:    path,
+		Vars:            config.ExtraVars,
+		VarFiles:        config.VarFiles,
+		EnvVars:         config.EnvVars,
 	}
 }
 
 // Run initializes a test context for a single example
 func Run(path string, config TestConfig) TestContext {
+	recordVars(path, config.ExtraVars)
+	return newContext(path, config)
+}
+
+// newContext initializes a test context like Run, without recording its vars for tftest coverage
+func newContext(path string, config TestConfig) TestContext {
 	tfOptions := InitTerraform(path, config)
 	return TestContext{
 		Config:      config,
 		Terraform:   tfOptions,
 		ExamplePath: path,
 		Name:        config.Name,
+		Executor:    NewExecutor(config),
+		outputs:     newOutputCache(),
+	}
+}
+
+// runInWorkspace initializes a test context and points Terraform at an isolated copy of the example if enabled
+// The copy is removed in t.Cleanup, after the cleanups registered later (such as destroy) have run
+func runInWorkspace(t testing.TB, examplePath string, config TestConfig) TestContext {
+	ctx := Run(examplePath, config)
+	if IsolationEnabled(config) {
+		ctx.WorkspacePath = CreateIsolatedWorkspace(t, examplePath)
+		ctx.Terraform.TerraformDir = ctx.WorkspacePath
+		t.Logf("Running example %s in isolated workspace %s", examplePath, ctx.WorkspacePath)
+	}
+	return ctx
+}
+
+// prepareExample initializes the test context for RunExample
+// A context persisted by an earlier run with a skipped destroy stage is resumed, otherwise a new one is created
+// Isolated workspaces are removed in t.Cleanup unless the destroy stage is skipped
+func prepareExample(t testing.TB, examplePath string, config TestConfig) (TestContext, bool) {
+	ctx, found, err := LoadContext(examplePath, config)
+	if err != nil {
+		t.Fatalf("Failed to load persisted context for %s: %v", config.Name, err)
+	}
+	if found {
+		t.Logf("Resuming persisted context for %s from %s", config.Name, ContextFile(examplePath, config.Name))
+		registerWorkspaceCleanup(t, ctx.workspaceRoot)
+		return ctx, true
+	}
+
+	ctx = Run(examplePath, config)
+	if IsolationEnabled(config) {
+		root, workspaceExample, err := CreateIsolatedWorkspaceE(examplePath)
+		registerWorkspaceCleanup(t, root)
+		if err != nil {
+			t.Fatalf("Failed to create isolated workspace for %s: %v", examplePath, err)
+		}
+		ctx.workspaceRoot = root
+		ctx.WorkspacePath = workspaceExample
+		ctx.Terraform.TerraformDir = workspaceExample
+		t.Logf("Running example %s in isolated workspace %s", examplePath, ctx.WorkspacePath)
 	}
+	return ctx, false
+}
+
+// registerWorkspaceCleanup removes an isolated workspace when the test finishes, unless it is kept for a later run
+func registerWorkspaceCleanup(t testing.TB, root string) {
+	if root == "" || !StageEnabled(StageDestroy) {
+		return
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(root)
+	})
 }
 
 // RunExample runs a single terraform example with the given config
 // and automatically performs an idempotency test unless disabled via TERRATEST_IDEMPOTENCY=false
-func RunExample(t *testing.T, examplePath string, config TestConfig) TestContext {
-	ctx := Run(examplePath, config)
-	terraform.InitAndApply(t, ctx.Terraform)
+// If plan-only mode is enabled via config.PlanOnly or TERRATEST_PLAN_ONLY=true, it delegates to RunExamplePlanOnly
+// The init, apply, idempotency and destroy stages can be skipped (see StageEnabled). When destroy is skipped,
+// the context is persisted to the example's .tftest directory and resumed by the next run
+// The settings of the example's tftest.yaml manifest are added to the config (see ApplyManifest)
+func RunExample(t testing.TB, examplePath string, config TestConfig) TestContext {
+	if config.PlanOnly || PlanOnlyEnabled() {
+		return RunExamplePlanOnly(t, examplePath, config)
+	}
+
+	start := time.Now()
+	config = exampleConfig(t, examplePath, config)
+	ctx, resumed := prepareExample(t, examplePath, config)
+	if !resumed && !StageEnabled(StageApply) {
+		t.Skipf("Skipping %s: the apply stage is skipped and no persisted context was found at %s",
+			config.Name, ContextFile(examplePath, config.Name))
+	}
+
+	// Register cleanup before applying so partially created resources are destroyed as well
+	if StageEnabled(StageDestroy) {
+		t.Cleanup(func() {
+			// Keep the persisted context if destroy fails so it can be retried with --only destroy
+			var err error
+			timePhase(t, string(StageDestroy), func() {
+				_, err = ctx.GetExecutor().Destroy(t, ctx.Terraform)
+			})
+			if err != nil {
+				t.Errorf("Failed to destroy %s: %v", ctx.Name, err)
+				return
+			}
+			if err := RemoveContext(ctx); err != nil {
+				t.Errorf("Failed to remove persisted context for %s: %v", ctx.Name, err)
+			}
+		})
+	} else {
+		if err := SaveContext(ctx); err != nil {
+			t.Fatalf("Failed to persist context for %s: %v", ctx.Name, err)
+		}
+		t.Logf("Skipping destroy stage, resources are kept and the context is persisted to %s",
+			ContextFile(examplePath, ctx.Name))
+	}
+
+	checkTimeout(t, ctx, start)
+	RunStage(t, StageInit, func() {
+		if _, err := ctx.GetExecutor().Init(t, ctx.Terraform); err != nil {
+			t.Fatalf("Failed to initialize %s: %v", ctx.Name, err)
+		}
+	})
+
+	checkTimeout(t, ctx, start)
+	RunStage(t, StageApply, func() {
+		ctx.ResetOutputs()
+		if _, err := ctx.GetExecutor().Apply(t, ctx.Terraform); err != nil {
+			t.Fatalf("Failed to apply %s: %v", ctx.Name, err)
+		}
+	})
 
 	// Run idempotency test by default unless explicitly disabled
-	if IdempotencyEnabled() {
-		t.Log("Running idempotency test...")
-		planOutput := terraform.Plan(t, ctx.Terraform)
-		// Check if the plan output contains "No changes" or "no changes"
-		if strings.Contains(planOutput, "No changes") || strings.Contains(planOutput, "no changes") {
-			t.Log("Idempotency test passed")
-		} else {
-			t.Fatalf("Idempotency test failed: Terraform plan would make changes: %s", planOutput)
+	// With config.MaxApplies > 1 the example is re-applied until it converges
+	checkTimeout(t, ctx, start)
+	RunStage(t, StageIdempotency, func() {
+		if !IdempotencyEnabled() {
+			t.Log("Idempotency testing disabled via TERRATEST_IDEMPOTENCY=false")
+		} else if ctx.Config.DisableIdempotency {
+			t.Logf("Idempotency testing disabled for %s", ctx.Name)
+		} else if !CheckConvergence(t, ctx) {
+			t.FailNow()
 		}
-	} else {
-		t.Log("Idempotency testing disabled via TERRATEST_IDEMPOTENCY=false")
+	})
+
+	// Compare the outputs with the expected outputs of the config
+	if len(ctx.Config.ExpectedOutputs) > 0 {
+		checkTimeout(t, ctx, start)
+		RunStage(t, StageValidate, func() {
+			checkExpectedOutputs(t, ctx)
+		})
 	}
 
-	// Register cleanup to ensure resources are destroyed
-	t.Cleanup(func() {
-		terraform.Destroy(t, ctx.Terraform)
+	return ctx
+}
+
+// RunExamplePlanOnly runs init and plan for a single terraform example without applying or destroying anything
+// The plan is written with plan -out, converted with terraform show -json and parsed into ctx.Plan
+// Outputs read through the context return the values known from the plan
+// The settings of the example's tftest.yaml manifest are added to the config (see ApplyManifest)
+func RunExamplePlanOnly(t testing.TB, examplePath string, config TestConfig) TestContext {
+	config = exampleConfig(t, examplePath, config)
+	ctx := runInWorkspace(t, examplePath, config)
+	ctx.Terraform.PlanFilePath = filepath.Join(t.TempDir(), "tfplan")
+
+	t.Log("Running in plan-only mode, no resources will be created")
+	timePhase(t, "plan", func() {
+		plan, err := InitAndPlanE(t, ctx)
+		if err != nil {
+			t.Fatalf("Failed to plan %s: %v", ctx.Name, err)
+		}
+		ctx.Plan = plan
 	})
 
 	return ctx
 }
 
+// InitAndPlanE runs init and plan for a context and parses the plan rendered by show -json
+// ctx.Terraform.PlanFilePath must be set so the plan can be rendered
+func InitAndPlanE(t terratesting.TestingT, ctx TestContext) (*terraform.PlanStruct, error) {
+	executor := ctx.GetExecutor()
+	if _, err := executor.Init(t, ctx.Terraform); err != nil {
+		return nil, err
+	}
+	if _, err := executor.Plan(t, ctx.Terraform); err != nil {
+		return nil, err
+	}
+
+	planJSON, err := executor.Show(t, ctx.Terraform)
+	if err != nil {
+		return nil, err
+	}
+	return terraform.ParsePlanJSON(planJSON)
+}
+
 // RunCustomTests runs a custom test function on all examples in the results map
 func RunCustomTests(t *testing.T, results map[string]TestContext, testFunc func(t *testing.T, ctx TestContext)) {
-	for _, ctx := range results {
-		testFunc(t, ctx)
-	}
+	RunStage(t, StageValidate, func() {
+		for _, ctx := range results {
+			testFunc(t, ctx)
+		}
+	})
 }
 
 // RunAllExamplesWithTests runs all examples and then runs multiple custom test functions on each example
@@ -108,15 +267,15 @@ func DiscoverAndRunAllTests(t *testing.T, moduleRootPath string, testFunc func(t
 
 	// If a test function is provided, run it on each example
 	if testFunc != nil {
-		for _, ctx := range results {
-			testFunc(t, ctx)
-		}
+		RunCustomTests(t, results, testFunc)
 	}
 
 	return results
 }
 
 // RunAllExamples runs all examples in the examples directory
+// Examples not selected by tftest run (see ExampleSelected) are skipped
+// Examples run after the examples they depend on in their tftest.yaml and are skipped if one of those does not pass
 // If configs is nil or empty, it will generate default configs for all examples
 // Parallelism is controlled by the TERRATEST_DISABLE_PARALLEL_TESTS environment variable
 func RunAllExamples(t *testing.T, moduleRootPath string, configs map[string]TestConfig) map[string]TestContext {
@@ -144,10 +303,10 @@ func RunAllExamples(t *testing.T, moduleRootPath string, configs map[string]Test
 		}
 	}
 
-	var wg sync.WaitGroup
-	results := make(map[string]TestContext)
-	resultsMutex := sync.Mutex{}
-
+	// Collect the examples to run with their dependencies declared in tftest.yaml
+	var names []string
+	paths := map[string]string{}
+	dependencies := map[string][]string{}
 	for _, entry := range entries {
 		if !entry.IsDir() {
 			continue
@@ -160,44 +319,92 @@ func RunAllExamples(t *testing.T, moduleRootPath string, configs map[string]Test
 			continue
 		}
 
-		examplePath := filepath.Join(moduleRootPath, exampleName)
+		// Skip if the example was not selected by tftest run
+		if !ExampleSelected(exampleName) {
+			t.Logf("Skipping example %s: not selected", exampleName)
+			continue
+		}
 
 		// Skip if no config provided for this example
-		config, exists := configs[exampleName]
-		if !exists {
+		if _, exists := configs[exampleName]; !exists {
 			t.Logf("Skipping example %s: no config provided", exampleName)
 			continue
 		}
 
+		examplePath := filepath.Join(moduleRootPath, exampleName)
+		m, err := LoadManifest(examplePath)
+		if err != nil {
+			t.Fatalf("Failed to load the manifest of %s: %v", exampleName, err)
+		}
+		names = append(names, exampleName)
+		paths[exampleName] = examplePath
+		dependencies[exampleName] = m.DependsOn
+	}
+
+	// Run every example after the examples it depends on
+	names, err = manifest.Order(names, dependencies)
+	if err != nil {
+		t.Fatalf("Failed to order examples: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	results := make(map[string]TestContext)
+	resultsMutex := sync.Mutex{}
+	done := make(map[string]chan struct{}, len(names))
+	passed := make(map[string]bool, len(names))
+	for _, name := range names {
+		done[name] = make(chan struct{})
+	}
+
+	runExample := func(name string) {
+		defer close(done[name])
+
+		// Wait for the dependencies, which run first, and skip the example if one of them did not pass
+		var failedDependency string
+		for _, dependency := range dependencies[name] {
+			if _, ok := done[dependency]; !ok {
+				continue
+			}
+			<-done[dependency]
+			resultsMutex.Lock()
+			ok := passed[dependency]
+			resultsMutex.Unlock()
+			if !ok && failedDependency == "" {
+				failedDependency = dependency
+			}
+		}
+
+		ok := t.Run(VersionedName(fmt.Sprintf("Example_%s", name)), func(t *testing.T) {
+			if failedDependency != "" {
+				t.Skipf("Skipping example %s: dependency %s did not pass", name, failedDependency)
+			}
+
+			ctx := RunExample(t, paths[name], configs[name])
+
+			// Store the result
+			resultsMutex.Lock()
+			results[name] = ctx
+			resultsMutex.Unlock()
+
+			// Note: RunExample now registers its own cleanup function
+			// so we don't need to destroy the example here
+		})
+
+		resultsMutex.Lock()
+		passed[name] = ok && failedDependency == ""
+		resultsMutex.Unlock()
+	}
+
+	for _, name := range names {
 		// Run tests in parallel or sequentially based on environment variable
 		if IsParallelTestsEnabled() {
 			wg.Add(1)
-			go func(name, path string, cfg TestConfig) {
+			go func(name string) {
 				defer wg.Done()
-
-				// Run the test in a subtest to isolate failures
-				t.Run(fmt.Sprintf("Example_%s", name), func(t *testing.T) {
-					ctx := RunExample(t, path, cfg)
-
-					// Store the result
-					resultsMutex.Lock()
-					results[name] = ctx
-					resultsMutex.Unlock()
-
-					// Note: RunExample now registers its own cleanup function
-					// so we don't need to call terraform.Destroy here
-				})
-			}(exampleName, examplePath, config)
+				runExample(name)
+			}(name)
 		} else {
-			// Run sequentially
-			t.Run(fmt.Sprintf("Example_%s", exampleName), func(t *testing.T) {
-				ctx := RunExample(t, examplePath, config)
-
-				// Store the result
-				resultsMutex.Lock()
-				results[exampleName] = ctx
-				resultsMutex.Unlock()
-			})
+			runExample(name)
 		}
 	}
 
@@ -207,7 +414,8 @@ func RunAllExamples(t *testing.T, moduleRootPath string, configs map[string]Test
 
 // RunSingleExample runs a specific example from the examples directory
 // This is useful when tests are organized by example (one test folder per example)
-func RunSingleExample(t *testing.T, moduleRootPath string, exampleName string, config TestConfig) TestContext {
+// The test is skipped if the example was not selected by tftest run (see ExampleSelected)
+func RunSingleExample(t testing.TB, moduleRootPath string, exampleName string, config TestConfig) TestContext {
 	var examplePath string
 
 	// Check if exampleName is "." which means use moduleRootPath directly
@@ -222,19 +430,23 @@ func RunSingleExample(t *testing.T, moduleRootPath string, exampleName string, c
 		t.Fatalf("Example %s not found at path %s", exampleName, examplePath)
 	}
 
-	// If no config provided, create a default one
+	if exampleName != "." && !ExampleSelected(exampleName) {
+		t.Skipf("Skipping example %s: not selected", exampleName)
+	}
+
+	// Default the name and vars, keeping the rest of the config
 	if config.Name == "" {
-		config = TestConfig{
-			Name:      exampleName,
-			ExtraVars: map[string]interface{}{},
-		}
+		config.Name = exampleName
+	}
+	if config.ExtraVars == nil {
+		config.ExtraVars = map[string]interface{}{}
 	}
 
 	// Run the example
 	ctx := RunExample(t, examplePath, config)
 
 	// Note: RunExample now registers its own cleanup function
-	// so we don't need to call terraform.Destroy here
+	// so we don't need to destroy the example here
 
 	return ctx
 }
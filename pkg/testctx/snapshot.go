@@ -0,0 +1,28 @@
+package testctx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SnapshotDir is the directory next to the tests that snapshots are stored in, e.g. tests/basic/__snapshots__
+const SnapshotDir = "__snapshots__"
+
+// SnapshotFile returns the path of a named snapshot, relative to the test package directory go test runs in
+func SnapshotFile(name string) string {
+	return filepath.Join(SnapshotDir, unsafeFileChars.ReplaceAllString(name, "_")+".json")
+}
+
+// PlanSnapshotFile returns the path of a named plan snapshot, kept apart from the snapshot of the applied example
+func PlanSnapshotFile(name string) string {
+	return filepath.Join(SnapshotDir, unsafeFileChars.ReplaceAllString(name, "_")+".plan.json")
+}
+
+// UpdateSnapshotsEnabled checks if snapshots should be rewritten instead of compared
+// Returns true only if TERRATEST_UPDATE_SNAPSHOTS is set to "true"
+// This is set by the tftest CLI when running with --update-snapshots
+func UpdateSnapshotsEnabled() bool {
+	val := os.Getenv("TERRATEST_UPDATE_SNAPSHOTS")
+	return strings.EqualFold(val, "true")
+}
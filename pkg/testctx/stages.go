@@ -0,0 +1,220 @@
+package testctx
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/results"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+)
+
+// Stage is a named step of the example lifecycle run by RunExample
+type Stage string
+
+const (
+	// StageInit runs terraform init
+	StageInit Stage = "init"
+	// StageApply runs terraform apply
+	StageApply Stage = "apply"
+	// StageIdempotency runs the idempotency (or convergence) check after apply
+	StageIdempotency Stage = "idempotency"
+	// StageValidate runs the custom tests and assertions on the applied example
+	StageValidate Stage = "validate"
+	// StageDestroy runs terraform destroy when the test finishes
+	StageDestroy Stage = "destroy"
+)
+
+// Stages lists all lifecycle stages in the order they run
+var Stages = []Stage{StageInit, StageApply, StageIdempotency, StageValidate, StageDestroy}
+
+// contextDir is the directory inside an example that persisted test contexts are written to
+const contextDir = ".tftest"
+
+// unsafeFileChars matches characters that are replaced when a test name is used as a file name
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// ParseStages parses a comma separated list of stage names
+func ParseStages(value string) ([]Stage, error) {
+	var stages []Stage
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		stage := Stage(strings.ToLower(name))
+		known := false
+		for _, s := range Stages {
+			if s == stage {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown stage %q, valid stages are %s", name, joinStages(Stages))
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+// StageEnabled checks if a lifecycle stage should run
+// If TERRATEST_ONLY_STAGES is set, only the stages it lists run; otherwise every stage runs
+// unless it is listed in TERRATEST_SKIP_STAGES. Both take a comma separated list of stage names
+func StageEnabled(stage Stage) bool {
+	if only := os.Getenv("TERRATEST_ONLY_STAGES"); strings.TrimSpace(only) != "" {
+		return containsStage(only, stage)
+	}
+	return !containsStage(os.Getenv("TERRATEST_SKIP_STAGES"), stage)
+}
+
+// RunStage runs fn if the stage is enabled and logs that it was skipped otherwise
+// It returns whether the stage ran. The duration of the stage is logged for the reports of tftest run
+func RunStage(t testing.TB, stage Stage, fn func()) bool {
+	if !StageEnabled(stage) {
+		t.Logf("Skipping %s stage", stage)
+		return false
+	}
+	timePhase(t, string(stage), fn)
+	return true
+}
+
+// timePhase runs fn and logs its duration in the format read back by results.ParsePhases
+// The duration is logged as well when fn fails the test with t.FailNow
+func timePhase(t testing.TB, phase string, fn func()) {
+	start := time.Now()
+	defer func() {
+		t.Log(results.FormatPhase(phase, time.Since(start)))
+	}()
+	fn()
+}
+
+// ContextFile returns the path a test context for an example and test name is persisted to
+// In version matrix runs the name is suffixed with the Terraform version, so each version keeps its own context
+func ContextFile(examplePath, name string) string {
+	if name == "" {
+		name = "default"
+	}
+	name = VersionedName(name)
+	return filepath.Join(examplePath, contextDir, unsafeFileChars.ReplaceAllString(name, "_")+".json")
+}
+
+// persistedContext is the on-disk representation of a TestContext used to resume skipped stages in a later run
+type persistedContext struct {
+	Name          string           `json:"name"`
+	ExamplePath   string           `json:"example_path"`
+	WorkspacePath string           `json:"workspace_path,omitempty"`
+	WorkspaceRoot string           `json:"workspace_root,omitempty"`
+	Terraform     persistedOptions `json:"terraform"`
+}
+
+// persistedOptions holds the terraform options needed to run Terraform against the persisted workspace
+type persistedOptions struct {
+	TerraformBinary string                 `json:"terraform_binary,omitempty"`
+	TerraformDir    string                 `json:"terraform_dir"`
+	Vars            map[string]interface{} `json:"vars,omitempty"`
+	VarFiles        []string               `json:"var_files,omitempty"`
+	EnvVars         map[string]string      `json:"env_vars,omitempty"`
+}
+
+// SaveContext persists the terraform options, vars and workspace path of a test context
+// so a later run with skipped init and apply stages can pick them up (see LoadContext)
+func SaveContext(ctx TestContext) error {
+	persisted := persistedContext{
+		Name:          ctx.Name,
+		ExamplePath:   ctx.ExamplePath,
+		WorkspacePath: ctx.WorkspacePath,
+		WorkspaceRoot: ctx.workspaceRoot,
+		Terraform: persistedOptions{
+			TerraformBinary: ctx.Terraform.TerraformBinary,
+			TerraformDir:    ctx.Terraform.TerraformDir,
+			Vars:            ctx.Terraform.Vars,
+			VarFiles:        ctx.Terraform.VarFiles,
+			EnvVars:         ctx.Terraform.EnvVars,
+		},
+	}
+
+	content, err := json.MarshalIndent(persisted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := ContextFile(ctx.ExamplePath, ctx.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// LoadContext loads the context persisted for an example and config by an earlier run
+// The returned context carries the current config, but the terraform options and workspace of the earlier run
+// It returns false if nothing was persisted or the persisted workspace no longer exists
+func LoadContext(examplePath string, config TestConfig) (TestContext, bool, error) {
+	content, err := os.ReadFile(ContextFile(examplePath, config.Name))
+	if os.IsNotExist(err) {
+		return TestContext{}, false, nil
+	}
+	if err != nil {
+		return TestContext{}, false, err
+	}
+
+	var persisted persistedContext
+	if err := json.Unmarshal(content, &persisted); err != nil {
+		return TestContext{}, false, fmt.Errorf("failed to parse persisted context %s: %w", ContextFile(examplePath, config.Name), err)
+	}
+
+	if _, err := os.Stat(persisted.Terraform.TerraformDir); os.IsNotExist(err) {
+		return TestContext{}, false, nil
+	}
+
+	return TestContext{
+		Config: config,
+		Terraform: &terraform.Options{
+			TerraformBinary: persisted.Terraform.TerraformBinary,
+			TerraformDir:    persisted.Terraform.TerraformDir,
+			Vars:            persisted.Terraform.Vars,
+			VarFiles:        persisted.Terraform.VarFiles,
+			EnvVars:         persisted.Terraform.EnvVars,
+		},
+		ExamplePath:   examplePath,
+		Name:          config.Name,
+		WorkspacePath: persisted.WorkspacePath,
+		Executor:      NewExecutor(config),
+		workspaceRoot: persisted.WorkspaceRoot,
+		outputs:       newOutputCache(),
+	}, true, nil
+}
+
+// RemoveContext deletes the persisted context of a test context, if any
+func RemoveContext(ctx TestContext) error {
+	err := os.Remove(ContextFile(ctx.ExamplePath, ctx.Name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// containsStage reports whether a comma separated list of stage names contains stage
+func containsStage(list string, stage Stage) bool {
+	for _, name := range strings.Split(list, ",") {
+		if Stage(strings.ToLower(strings.TrimSpace(name))) == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// joinStages joins stage names with commas
+func joinStages(stages []Stage) string {
+	names := make([]string, len(stages))
+	for i, stage := range stages {
+		names[i] = string(stage)
+	}
+	return strings.Join(names, ", ")
+}
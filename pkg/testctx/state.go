@@ -0,0 +1,123 @@
+package testctx
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/attrpath"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// StateResource is a typed view of a single resource instance in the Terraform state
+type StateResource struct {
+	// Address is the full resource address, e.g. module.x.aws_s3_bucket.b["logs"]
+	Address string
+	// ModulePath is the address of the containing module, empty for the root module
+	ModulePath string
+	Mode       string
+	Type       string
+	Name       string
+	// IndexKey is the count index (int) or for_each key (string), nil if neither is used
+	IndexKey   interface{}
+	Attributes map[string]interface{}
+}
+
+// Attribute resolves a dotted attribute path (e.g. "versioning.0.enabled") against the resource attributes
+func (r StateResource) Attribute(path string) (interface{}, bool) {
+	return attrpath.Lookup(r.Attributes, path)
+}
+
+// State is the parsed representation of the output of terraform show -json
+type State struct {
+	TerraformVersion string
+	Resources        []StateResource
+}
+
+// Resource returns the resource instance with the given full address
+func (s *State) Resource(address string) (StateResource, bool) {
+	for _, resource := range s.Resources {
+		if resource.Address == address {
+			return resource, true
+		}
+	}
+	return StateResource{}, false
+}
+
+// ResourcesOfType returns all managed resource instances of the given type in any module
+func (s *State) ResourcesOfType(resourceType string) []StateResource {
+	var resources []StateResource
+	for _, resource := range s.Resources {
+		if resource.Mode == string(tfjson.ManagedResourceMode) && resource.Type == resourceType {
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
+// ParseState parses the JSON state representation returned by terraform show -json
+func ParseState(jsonStr string) (*State, error) {
+	var raw tfjson.State
+	if err := json.Unmarshal([]byte(jsonStr), &raw); err != nil {
+		return nil, err
+	}
+
+	state := &State{TerraformVersion: raw.TerraformVersion}
+	if raw.Values != nil && raw.Values.RootModule != nil {
+		state.Resources = collectStateResources(raw.Values.RootModule)
+	}
+	return state, nil
+}
+
+// GetState runs terraform show -json against the current state and parses the result
+func (ctx TestContext) GetState(t testing.TB) *State {
+	// Clear the plan file so terraform show reads the state instead of a saved plan
+	options := *ctx.Terraform
+	options.PlanFilePath = ""
+
+	stateJSON, err := ctx.GetExecutor().Show(t, &options)
+	if err != nil {
+		t.Fatalf("Failed to show Terraform state for %s: %v", ctx.Name, err)
+	}
+
+	state, err := ParseState(stateJSON)
+	if err != nil {
+		t.Fatalf("Failed to parse Terraform state for %s: %v", ctx.Name, err)
+	}
+	return state
+}
+
+// collectStateResources flattens the resources of a module and all its child modules
+func collectStateResources(module *tfjson.StateModule) []StateResource {
+	var resources []StateResource
+	for _, resource := range module.Resources {
+		resources = append(resources, StateResource{
+			Address:    resource.Address,
+			ModulePath: module.Address,
+			Mode:       string(resource.Mode),
+			Type:       resource.Type,
+			Name:       resource.Name,
+			IndexKey:   normalizeIndexKey(resource.Index),
+			Attributes: resource.AttributeValues,
+		})
+	}
+
+	for _, child := range module.ChildModules {
+		resources = append(resources, collectStateResources(child)...)
+	}
+	return resources
+}
+
+// normalizeIndexKey converts JSON decoded count indexes to int and leaves for_each keys untouched
+func normalizeIndexKey(index interface{}) interface{} {
+	switch key := index.(type) {
+	case float64:
+		return int(key)
+	case json.Number:
+		if value, err := key.Int64(); err == nil {
+			return int(value)
+		}
+		return key.String()
+	default:
+		return key
+	}
+}
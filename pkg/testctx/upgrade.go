@@ -0,0 +1,300 @@
+package testctx
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/logger"
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+)
+
+// UpgradeResult holds the outcome of an upgrade check between two module sources
+type UpgradeResult struct {
+	// Terraform holds the options of the temporary workspace the example was applied in
+	Terraform *terraform.Options
+	// Plan is the plan produced after swapping to the new module source
+	Plan *terraform.PlanStruct
+	// Destructive lists replacements and destroys that are not covered by the allowlist
+	Destructive []ResourceDiff
+	// Allowed lists replacements and destroys that matched config.UpgradeAllowlist
+	Allowed []ResourceDiff
+
+	cleanups []func(t terratesting.TestingT)
+}
+
+// Cleanup destroys the applied resources and removes the temporary workspace and git worktrees
+func (r *UpgradeResult) Cleanup(t terratesting.TestingT) {
+	// Run in reverse order so resources are destroyed before their workspace is removed
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i](t)
+	}
+	r.cleanups = nil
+}
+
+// RunUpgradeTest applies an example against the module source at fromRef, swaps the module source to toRef and plans
+// The test fails if the plan replaces or destroys any resource not listed in config.UpgradeAllowlist
+// A ref is either a local path to a module checkout, absolute or starting with ./ or ../, or a git ref of the
+// repository containing the module, which is checked out into a temporary worktree. An empty toRef uses the current
+// working tree
+func RunUpgradeTest(t *testing.T, examplePath, fromRef, toRef string, config TestConfig) TestContext {
+	result, err := RunUpgradeCheckE(t, examplePath, fromRef, toRef, config)
+	t.Cleanup(func() {
+		result.Cleanup(t)
+	})
+	if err != nil {
+		t.Fatalf("Upgrade test from %s to %s failed: %v", fromRef, describeRef(toRef), err)
+	}
+
+	if len(result.Allowed) > 0 {
+		t.Logf("Allowed destructive changes: %s", PlanDiff{Resources: result.Allowed})
+	}
+	if len(result.Destructive) > 0 {
+		t.Errorf("Upgrade from %s to %s would replace or destroy resources: %s",
+			fromRef, describeRef(toRef), PlanDiff{Resources: result.Destructive})
+	}
+
+	return TestContext{
+		Config:      config,
+		Terraform:   result.Terraform,
+		ExamplePath: examplePath,
+		Name:        config.Name,
+		Plan:        result.Plan,
+		Executor:    NewExecutor(config),
+		outputs:     newOutputCache(),
+	}
+}
+
+// RunUpgradeCheckE runs the upgrade flow of RunUpgradeTest and returns its result instead of failing a test
+// The returned result is never nil, and its Cleanup method must be called even if an error is returned
+func RunUpgradeCheckE(t terratesting.TestingT, examplePath, fromRef, toRef string, config TestConfig) (*UpgradeResult, error) {
+	result := &UpgradeResult{}
+
+	absExample, err := filepath.Abs(examplePath)
+	if err != nil {
+		return result, err
+	}
+	moduleRoot, err := upgradeModuleRoot(absExample)
+	if err != nil {
+		return result, err
+	}
+
+	fromTree, err := result.checkoutModuleRef(t, moduleRoot, fromRef)
+	if err != nil {
+		return result, err
+	}
+	toTree, err := result.checkoutModuleRef(t, moduleRoot, toRef)
+	if err != nil {
+		return result, err
+	}
+
+	// The copy keeps the layout around the example, so relative references such as file("../../config.tpl")
+	// and var files outside the example still resolve
+	workspace, workspaceExample, err := CreateIsolatedWorkspaceE(absExample)
+	if workspace != "" {
+		result.cleanups = append(result.cleanups, func(t terratesting.TestingT) {
+			os.RemoveAll(workspace)
+		})
+	}
+	if err != nil {
+		return result, err
+	}
+
+	options := InitTerraform(workspaceExample, config)
+	executor := NewExecutor(config)
+	result.Terraform = options
+
+	logger.Logf(t, "Applying %s against module source %s", config.Name, fromTree)
+	if err := pointModuleSources(workspaceExample, absExample, moduleRoot, fromTree); err != nil {
+		return result, err
+	}
+	result.cleanups = append(result.cleanups, func(t terratesting.TestingT) {
+		// Destroy the configuration that was applied, not the one the sources were swapped to for the plan
+		if err := pointModuleSources(workspaceExample, absExample, moduleRoot, fromTree); err != nil {
+			t.Errorf("Failed to point %s back at module source %s for destroy: %v", config.Name, fromTree, err)
+			return
+		}
+		if _, err := executor.Init(t, options); err != nil {
+			t.Errorf("Failed to initialize %s for destroy: %v", config.Name, err)
+			return
+		}
+		if _, err := executor.Destroy(t, options); err != nil {
+			t.Errorf("Failed to destroy upgrade test resources for %s: %v", config.Name, err)
+		}
+	})
+	if _, err := executor.Init(t, options); err != nil {
+		return result, err
+	}
+	if _, err := executor.Apply(t, options); err != nil {
+		return result, err
+	}
+
+	logger.Logf(t, "Planning %s against module source %s", config.Name, toTree)
+	if err := pointModuleSources(workspaceExample, absExample, moduleRoot, toTree); err != nil {
+		return result, err
+	}
+	planOptions := *options
+	planOptions.PlanFilePath = filepath.Join(workspace, "upgrade.tfplan")
+	result.Plan, err = InitAndPlanE(t, TestContext{Config: config, Terraform: &planOptions, Name: config.Name, Executor: executor})
+	if err != nil {
+		return result, err
+	}
+
+	for _, resource := range DiffPlan(result.Plan).Resources {
+		if !isDestructive(resource.Actions) {
+			continue
+		}
+		if matchesAny(config.UpgradeAllowlist, resource.Address) {
+			result.Allowed = append(result.Allowed, resource)
+		} else {
+			result.Destructive = append(result.Destructive, resource)
+		}
+	}
+
+	return result, nil
+}
+
+// upgradeModuleRoot returns the root of the module an example is upgraded against: the closest common parent of the
+// local module sources the example references outside its own directory, e.g. <module> for source = "../../"
+func upgradeModuleRoot(absExample string) (string, error) {
+	sources, err := localModuleSources(absExample)
+	if err != nil {
+		return "", err
+	}
+
+	root := ""
+	for _, source := range sources {
+		if isWithin(absExample, source) {
+			continue
+		}
+		if root == "" {
+			root = source
+		}
+		for !isWithin(root, source) {
+			root = filepath.Dir(root)
+		}
+	}
+	if root == "" {
+		return "", fmt.Errorf("example %s does not reference a local module source outside its directory", absExample)
+	}
+	return root, nil
+}
+
+// checkoutModuleRef resolves a ref to the directory holding that version of the module
+// Refs written as paths are used as is, an empty ref is the current module root, anything else is checked out with
+// git worktree, even if a directory of the same name exists
+func (r *UpgradeResult) checkoutModuleRef(t terratesting.TestingT, moduleRoot, ref string) (string, error) {
+	if ref == "" {
+		return moduleRoot, nil
+	}
+	if isPathRef(ref) {
+		if info, err := os.Stat(ref); err != nil || !info.IsDir() {
+			return "", fmt.Errorf("module path %s is not a directory", ref)
+		}
+		return filepath.Abs(ref)
+	}
+
+	toplevel, err := runGit(moduleRoot, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("ref %s is not a local path and %s is not a git repository: %w", ref, moduleRoot, err)
+	}
+
+	realModuleRoot, err := filepath.EvalSymlinks(moduleRoot)
+	if err != nil {
+		return "", err
+	}
+	relModule, err := filepath.Rel(toplevel, realModuleRoot)
+	if err != nil {
+		return "", err
+	}
+
+	tempDir, err := os.MkdirTemp("", "tftest-ref-")
+	if err != nil {
+		return "", err
+	}
+	worktree := filepath.Join(tempDir, "worktree")
+
+	logger.Logf(t, "Checking out %s into %s", ref, worktree)
+	if _, err := runGit(toplevel, "worktree", "add", "--detach", worktree, ref); err != nil {
+		os.RemoveAll(tempDir)
+		return "", fmt.Errorf("failed to check out ref %s: %w", ref, err)
+	}
+	r.cleanups = append(r.cleanups, func(t terratesting.TestingT) {
+		if _, err := runGit(toplevel, "worktree", "remove", "--force", worktree); err != nil {
+			logger.Logf(t, "Failed to remove worktree %s: %v", worktree, err)
+		}
+		os.RemoveAll(tempDir)
+	})
+
+	return filepath.Join(worktree, relModule), nil
+}
+
+// isPathRef reports whether a ref is a local path: absolute, or relative starting with ./ or ../
+func isPathRef(ref string) bool {
+	if filepath.IsAbs(ref) || ref == "." || ref == ".." {
+		return true
+	}
+	slashed := filepath.ToSlash(ref)
+	return strings.HasPrefix(slashed, "./") || strings.HasPrefix(slashed, "../")
+}
+
+// pointModuleSources restores the example's .tf files in its workspace copy and points their module sources at tree
+// Sources inside the example stay relative, sources inside the module root are mapped into tree
+func pointModuleSources(workspaceExample, exampleDir, moduleRoot, tree string) error {
+	files, err := filepath.Glob(filepath.Join(exampleDir, "*.tf"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := copyFile(file, filepath.Join(workspaceExample, filepath.Base(file)), 0644); err != nil {
+			return err
+		}
+	}
+
+	return rewriteLocalSources(workspaceExample, exampleDir, func(source, absSource string) string {
+		switch {
+		case isWithin(exampleDir, absSource):
+			return source
+		case isWithin(moduleRoot, absSource):
+			relSource, err := filepath.Rel(moduleRoot, absSource)
+			if err != nil {
+				return absSource
+			}
+			return filepath.Join(tree, relSource)
+		default:
+			return absSource
+		}
+	})
+}
+
+// isDestructive reports whether a set of planned actions deletes or replaces a resource
+func isDestructive(actions []string) bool {
+	for _, action := range actions {
+		if action == "delete" {
+			return true
+		}
+	}
+	return false
+}
+
+// runGit runs a git command in dir and returns its trimmed output
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// describeRef returns a readable name for a ref, where an empty ref is the current working tree
+func describeRef(ref string) string {
+	if ref == "" {
+		return "the working tree"
+	}
+	return ref
+}
@@ -0,0 +1,96 @@
+package testctx
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VersionsFile is the file in the module root listing the Terraform versions of a version matrix run
+const VersionsFile = ".terraform-versions"
+
+// TerraformVersion returns the Terraform version of the current version matrix run
+// It is set by tftest run --terraform-versions via TERRATEST_TERRAFORM_VERSION and empty outside matrix runs
+func TerraformVersion() string {
+	return strings.TrimPrefix(strings.TrimSpace(os.Getenv("TERRATEST_TERRAFORM_VERSION")), "v")
+}
+
+// VersionedName suffixes a test name with the Terraform version of the current version matrix run
+// e.g. "basic" becomes "basic@1.5.7". Outside matrix runs, and for empty or already suffixed names, the name is returned unchanged
+func VersionedName(name string) string {
+	version := TerraformVersion()
+	if version == "" || name == "" || strings.HasSuffix(name, "@"+version) {
+		return name
+	}
+	return name + "@" + version
+}
+
+// VersionCacheDir returns the directory holding the Terraform binaries of a version matrix run
+// It is read from TERRATEST_TERRAFORM_CACHE_DIR and defaults to ~/.tftest/terraform
+func VersionCacheDir() string {
+	if dir := os.Getenv("TERRATEST_TERRAFORM_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".tftest", "terraform")
+	}
+	return filepath.Join(home, ".tftest", "terraform")
+}
+
+// FindCachedBinary returns the path of the binary for a version in the cache directory
+// The binary is looked up as <cacheDir>/<version>/<binary>, <cacheDir>/<binary>_<version> and <cacheDir>/<binary>-<version>,
+// which covers the layouts of tfenv style version directories and of renamed release binaries
+func FindCachedBinary(cacheDir, binary, version string) (string, error) {
+	if binary == "" {
+		binary = BinaryTerraform
+	}
+	version = strings.TrimPrefix(version, "v")
+
+	candidates := []string{
+		filepath.Join(cacheDir, version, binary),
+		filepath.Join(cacheDir, binary+"_"+version),
+		filepath.Join(cacheDir, binary+"-"+version),
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%s %s not found in %s, looked for %s", binary, version, cacheDir, strings.Join(candidates, ", "))
+}
+
+// ParseVersions splits a comma separated list of versions, dropping empty entries and a leading "v"
+func ParseVersions(value string) []string {
+	var versions []string
+	for _, version := range strings.Split(value, ",") {
+		version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+		if version != "" {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// ReadVersionsFile reads the Terraform versions of a version matrix run from a file
+// Versions are separated by newlines or commas; blank lines and lines starting with # are ignored
+func ReadVersionsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var versions []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		versions = append(versions, ParseVersions(line)...)
+	}
+	return versions, scanner.Err()
+}
@@ -0,0 +1,253 @@
+package testctx
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// WorkspaceMode controls whether Terraform runs inside the example directory or in an isolated copy of it
+type WorkspaceMode string
+
+const (
+	// WorkspaceAuto isolates examples when parallel tests are enabled and runs them in place otherwise
+	WorkspaceAuto WorkspaceMode = ""
+	// WorkspaceIsolated always runs examples in a temporary copy
+	WorkspaceIsolated WorkspaceMode = "isolated"
+	// WorkspaceInPlace always runs examples inside the example directory
+	WorkspaceInPlace WorkspaceMode = "in-place"
+)
+
+// localSourceRegex matches local module sources such as source = "../../" or source = "./modules/x"
+var localSourceRegex = regexp.MustCompile(`(?m)^(\s*source\s*=\s*")(\.\.?(?:/[^"]*)?)(")`)
+
+// skippedWorkspaceEntries are never copied into a workspace because they hold state or cached plugins
+var skippedWorkspaceEntries = map[string]bool{
+	".git":                     true,
+	".terraform":               true,
+	".tftest":                  true,
+	"terraform.tfstate":        true,
+	"terraform.tfstate.backup": true,
+}
+
+// IsolationEnabled reports whether an example run with the given config should use an isolated workspace
+// With WorkspaceAuto, isolation follows IsParallelTestsEnabled so parallel runs never share .terraform or state
+func IsolationEnabled(config TestConfig) bool {
+	switch config.Workspace {
+	case WorkspaceIsolated:
+		return true
+	case WorkspaceInPlace:
+		return false
+	default:
+		return IsParallelTestsEnabled()
+	}
+}
+
+// CreateIsolatedWorkspace copies an example and the local module sources it references into a temporary directory
+// It returns the path of the example inside the copy, which is removed when the test finishes
+func CreateIsolatedWorkspace(t testing.TB, examplePath string) string {
+	workspace, examplePathInWorkspace, err := CreateIsolatedWorkspaceE(examplePath)
+	if workspace != "" {
+		t.Cleanup(func() {
+			os.RemoveAll(workspace)
+		})
+	}
+	if err != nil {
+		t.Fatalf("Failed to create isolated workspace for %s: %v", examplePath, err)
+	}
+	return examplePathInWorkspace
+}
+
+// CreateIsolatedWorkspaceE copies an example and the local module sources it references (directly or through other
+// local modules) into a new temporary directory. Subdirectories holding their own Terraform configuration or Go tests,
+// such as the other examples and the tests of the module, are not copied unless they are referenced themselves
+// The layout relative to the closest common parent is preserved, so sources such as "../../" and relative file
+// references into the module stay resolvable without being rewritten
+// It returns the temporary directory, which the caller must remove, and the path of the example inside it
+func CreateIsolatedWorkspaceE(examplePath string) (string, string, error) {
+	absExample, err := filepath.Abs(examplePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	moduleDirs := map[string]bool{}
+	if err := collectModuleDirs(absExample, moduleDirs); err != nil {
+		return "", "", err
+	}
+
+	root := absExample
+	for dir := range moduleDirs {
+		for !isWithin(root, dir) {
+			root = filepath.Dir(root)
+		}
+	}
+
+	workspace, err := os.MkdirTemp("", "tftest-workspace-")
+	if err != nil {
+		return "", "", err
+	}
+	if isWithin(root, workspace) {
+		return workspace, "", fmt.Errorf("cannot copy %s into a workspace inside it", root)
+	}
+	for dir := range moduleDirs {
+		relDir, err := filepath.Rel(root, dir)
+		if err != nil {
+			return workspace, "", err
+		}
+		if err := copyModuleDir(dir, filepath.Join(workspace, relDir)); err != nil {
+			return workspace, "", err
+		}
+	}
+
+	relExample, err := filepath.Rel(root, absExample)
+	if err != nil {
+		return workspace, "", err
+	}
+	return workspace, filepath.Join(workspace, relExample), nil
+}
+
+// collectModuleDirs adds dir and every local module directory it references, recursively, to dirs
+func collectModuleDirs(dir string, dirs map[string]bool) error {
+	if dirs[dir] {
+		return nil
+	}
+	dirs[dir] = true
+
+	sources, err := localModuleSources(dir)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if err := collectModuleDirs(source, dirs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyModuleDir recursively copies a module directory from src into dst, skipping Terraform state, caches and VCS
+// metadata, and the subdirectories holding their own Terraform configuration or Go tests, which are separate modules
+// copied on their own if referenced
+func copyModuleDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != src && (skippedWorkspaceEntries[info.Name()] || (info.IsDir() && holdsModule(path))) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, relPath)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+// holdsModule reports whether dir holds its own Terraform configuration or Go tests
+func holdsModule(dir string) bool {
+	for _, pattern := range []string{"*.tf", "*.go"} {
+		if matches, err := filepath.Glob(filepath.Join(dir, pattern)); err == nil && len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// copyFile copies a single file preserving its permissions
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// localModuleSources returns the absolute paths of all local module sources referenced by the .tf files in dir
+func localModuleSources(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range localSourceRegex.FindAllStringSubmatch(string(content), -1) {
+			sources = append(sources, filepath.Clean(filepath.Join(dir, match[2])))
+		}
+	}
+	return sources, nil
+}
+
+// rewriteLocalSources rewrites the local module sources in the .tf files of dir
+// Each source is resolved against originDir and the result of resolve replaces the original source string
+func rewriteLocalSources(dir, originDir string, resolve func(source, absSource string) string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		rewritten := localSourceRegex.ReplaceAllStringFunc(string(content), func(match string) string {
+			parts := localSourceRegex.FindStringSubmatch(match)
+			absSource := filepath.Clean(filepath.Join(originDir, parts[2]))
+			return parts[1] + filepath.ToSlash(resolve(parts[2], absSource)) + parts[3]
+		})
+
+		if rewritten != string(content) {
+			if err := os.WriteFile(file, []byte(rewritten), 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isWithin reports whether path is equal to or nested inside dir
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
@@ -0,0 +1,19 @@
+package functional
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// TestExampleSuitePlanOnly runs the tests of the example module in plan-only mode
+// Nothing is applied, so the suite must pass without creating any infrastructure
+func TestExampleSuitePlanOnly(t *testing.T) {
+	cmd := exec.Command("go", "test", "-count=1", "./tests/...")
+	cmd.Dir = "../../example"
+	cmd.Env = append(os.Environ(), "TERRATEST_PLAN_ONLY=true")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Example tests failed in plan-only mode: %v\nOutput: %s", err, output)
+	}
+}
@@ -171,6 +171,24 @@ func TestAssertResourceCount(t *testing.T) {
 	})
 }
 
+// TestAssertResourceAddressExists tests the AssertResourceAddressExists function
+func TestAssertResourceAddressExists(t *testing.T) {
+	t.Run("Function exists", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			_ = assertions.AssertResourceAddressExists
+		})
+	})
+}
+
+// TestAssertResourceAttribute tests the AssertResourceAttribute function
+func TestAssertResourceAttribute(t *testing.T) {
+	t.Run("Function exists", func(t *testing.T) {
+		assert.NotPanics(t, func() {
+			_ = assertions.AssertResourceAttribute
+		})
+	})
+}
+
 // TestAssertNoResourcesOfType tests the AssertNoResourcesOfType function
 func TestAssertNoResourcesOfType(t *testing.T) {
 	t.Run("Function exists", func(t *testing.T) {
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/terraform"
@@ -44,3 +45,28 @@ func (m *MockTestContextSimple) SetOutput(key, value string) {
 type MockTerraformOptions struct {
 	mock.Mock
 }
+
+// MockT records assertion failures instead of failing the running test
+// It embeds testing.TB so it can be passed to assertions, but only the methods used by testify are implemented
+type MockT struct {
+	testing.TB
+	Messages []string
+}
+
+// Helper is a no-op to satisfy testify's tHelper interface
+func (m *MockT) Helper() {}
+
+// Errorf records a failure message
+func (m *MockT) Errorf(format string, args ...interface{}) {
+	m.Messages = append(m.Messages, fmt.Sprintf(format, args...))
+}
+
+// Failed reports whether any failure was recorded
+func (m *MockT) Failed() bool {
+	return len(m.Messages) > 0
+}
+
+// Name returns a fixed name used in failure messages
+func (m *MockT) Name() string {
+	return "MockT"
+}
@@ -67,3 +67,21 @@ func TestIdempotencyEnabled(t *testing.T) {
 	// Reset for other tests
 	os.Unsetenv("TERRATEST_IDEMPOTENCY")
 }
+
+// Test the PlanOnlyEnabled function
+func TestPlanOnlyEnabled(t *testing.T) {
+	// Test default behavior (disabled)
+	os.Unsetenv("TERRATEST_PLAN_ONLY")
+	assert.False(t, testctx.PlanOnlyEnabled())
+
+	// Test explicitly enabled
+	os.Setenv("TERRATEST_PLAN_ONLY", "true")
+	assert.True(t, testctx.PlanOnlyEnabled())
+
+	// Test any other value
+	os.Setenv("TERRATEST_PLAN_ONLY", "false")
+	assert.False(t, testctx.PlanOnlyEnabled())
+
+	// Reset for other tests
+	os.Unsetenv("TERRATEST_PLAN_ONLY")
+}
@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/coverage"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// writeCoverageModule writes a module with an example forwarding some of its variables and outputs
+func writeCoverageModule(t *testing.T) string {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"variables.tf": `variable "name" {}
+variable "size" {}
+variable "tags" {}
+variable "region" {}
+`,
+		"outputs.tf": `output "id" {
+  value = "id"
+}
+
+output "arn" {
+  value = "arn"
+}
+`,
+		"examples/basic/main.tf": `variable "name" {}
+variable "size" {
+  default = 1
+}
+variable "extra_tags" {
+  default = {}
+}
+
+module "example" {
+  source = "../../"
+  name   = var.name
+  size   = var.size
+  tags   = var.extra_tags
+  region = "us-east-1"
+}
+
+output "example_id" {
+  value = module.example.id
+}
+
+output "example_arn" {
+  value = module.example.arn
+}
+`,
+		"examples/basic/terraform.tfvars": `name = "basic"
+`,
+	})
+	return root
+}
+
+func TestCoverageAnalyze(t *testing.T) {
+	root := writeCoverageModule(t)
+	example := filepath.Join(root, "examples", "basic")
+
+	// Without records, only the variables set by the example itself are covered
+	report, err := coverage.Analyze(root, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []coverage.Item{
+		{Name: "name", CoveredBy: []string{"basic"}},
+		{Name: "size", CoveredBy: []string{}},
+		{Name: "tags", CoveredBy: []string{}},
+		{Name: "region", CoveredBy: []string{"basic"}},
+	}, report.Variables)
+	assert.Equal(t, []string{"size", "tags"}, report.UntestedVariables)
+	assert.Equal(t, []string{"id", "arn"}, report.UnassertedOutputs)
+
+	// Recorded ExtraVars and output reads cover the variables and outputs they flow to
+	records := []coverage.Record{
+		{Example: example, Vars: []string{"extra_tags"}},
+		{Example: example, Outputs: []string{"example_arn"}},
+	}
+	report, err = coverage.Analyze(root, records)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"size"}, report.UntestedVariables)
+	assert.Equal(t, []string{"id"}, report.UnassertedOutputs)
+	assert.Equal(t, coverage.Item{Name: "arn", CoveredBy: []string{"basic"}}, report.Outputs[1])
+
+	text := report.String()
+	assert.Contains(t, text, "Variables: 3/4 tested, untested: size")
+	assert.Contains(t, text, "Outputs: 1/2 asserted, unasserted: id")
+}
+
+func TestCoverageRecording(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TERRATEST_COVERAGE_DIR", dir)
+
+	examplePath := t.TempDir()
+	ctx := testctx.Run(examplePath, testctx.TestConfig{Name: "coverage", ExtraVars: map[string]interface{}{"size": 2}})
+	ctx.Executor = fake.Load(t, filepath.Join("testdata", "fake", "outputs"))
+
+	assertions.AssertOutputEquals(t, ctx, "bucket_name", "example-bucket")
+	ctx.GetOutput(t, "bucket_name")
+	var regions []string
+	ctx.DecodeOutput(t, "regions", &regions)
+	ctx.GetAllOutputs(t)
+
+	records, err := coverage.ReadRecords(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []coverage.Record{
+		{Example: examplePath, Vars: []string{"size"}},
+		{Example: examplePath, Outputs: []string{"bucket_name"}},
+		{Example: examplePath, Outputs: []string{"regions"}},
+	}, records, "Each var and output should be recorded once, and reading all outputs should not record them")
+
+	// A snapshot asserts all outputs
+	chdir(t, t.TempDir())
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "true")
+	assertions.AssertMatchesSnapshot(t, ctx, "coverage")
+	records, err = coverage.ReadRecords(dir)
+	require.NoError(t, err)
+	assert.Equal(t, coverage.Record{Example: examplePath, Outputs: []string{"tags"}}, records[len(records)-1])
+
+	// Nothing is recorded without a coverage directory
+	t.Setenv("TERRATEST_COVERAGE_DIR", "")
+	ctx.GetOutput(t, "tags")
+	records, err = coverage.ReadRecords(dir)
+	require.NoError(t, err)
+	assert.Len(t, records, 4)
+}
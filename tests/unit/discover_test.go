@@ -51,3 +51,22 @@ func TestDiscoverExamples(t *testing.T) {
 	assert.NotContains(t, examples, "another-dir")
 	assert.NotContains(t, examples, "some-file.txt")
 }
+
+func TestDiscoverExamplesSelection(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, dir := range []string{"example-basic", "example-advanced", "example-eks"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(tempDir, dir), 0755))
+	}
+
+	// tftest run passes the selected examples by their directory name under examples/
+	t.Setenv("TERRATEST_EXAMPLES", "basic, example-eks")
+	assert.Equal(t, []string{"basic", "example-eks"}, testctx.SelectedExamples())
+	assert.True(t, testctx.ExampleSelected("basic"))
+	assert.True(t, testctx.ExampleSelected("example-basic"))
+	assert.False(t, testctx.ExampleSelected("advanced"))
+	assert.Equal(t, []string{"example-basic", "example-eks"}, testctx.DiscoverExamples(t, tempDir))
+
+	t.Setenv("TERRATEST_EXAMPLES", "")
+	assert.Nil(t, testctx.SelectedExamples())
+	assert.True(t, testctx.ExampleSelected("advanced"))
+}
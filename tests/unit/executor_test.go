@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	terratesting "github.com/gruntwork-io/terratest/modules/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+)
+
+// outputExecutor is a minimal executor serving fixed output JSON
+type outputExecutor struct {
+	testctx.CLIExecutor
+	outputs map[string]string
+}
+
+func (e *outputExecutor) Output(t terratesting.TestingT, options *terraform.Options, key string) (string, error) {
+	return e.outputs[key], nil
+}
+
+func TestNewExecutor(t *testing.T) {
+	defer os.Unsetenv("TERRATEST_BINARY")
+	os.Unsetenv("TERRATEST_BINARY")
+
+	assert.Equal(t, &testctx.CLIExecutor{}, testctx.NewExecutor(testctx.TestConfig{}), "Default executor should use terratest's default binary")
+	assert.Equal(t, testctx.NewTofuExecutor(""), testctx.NewExecutor(testctx.TestConfig{Binary: "tofu"}))
+	assert.Equal(t, &testctx.CLIExecutor{Binary: "/opt/bin/tofu-1.8"}, testctx.NewExecutor(testctx.TestConfig{Binary: "/opt/bin/tofu-1.8"}))
+	assert.Equal(t, &testctx.CLIExecutor{Binary: "/opt/bin/terraform"}, testctx.NewExecutor(testctx.TestConfig{Binary: "/opt/bin/terraform"}))
+
+	// TERRATEST_BINARY is used when the config does not select a binary
+	os.Setenv("TERRATEST_BINARY", "tofu")
+	assert.Equal(t, "tofu", testctx.ConfiguredBinary(testctx.TestConfig{}))
+	assert.Equal(t, "terraform", testctx.ConfiguredBinary(testctx.TestConfig{Binary: "terraform"}))
+
+	// An explicit executor takes precedence
+	executor := &outputExecutor{}
+	assert.Same(t, executor, testctx.NewExecutor(testctx.TestConfig{Binary: "terraform", Executor: executor}))
+}
+
+func TestParseVersionJSON(t *testing.T) {
+	info, err := testctx.ParseVersionJSON(`{
+  "terraform_version": "1.9.0",
+  "platform": "linux_amd64",
+  "provider_selections": {"registry.terraform.io/hashicorp/local": "2.5.1"}
+}`)
+	require.NoError(t, err)
+	assert.Equal(t, "1.9.0", info.Version)
+	assert.Equal(t, "linux_amd64", info.Platform)
+	assert.Equal(t, "2.5.1", info.ProviderSelections["registry.terraform.io/hashicorp/local"])
+
+	_, err = testctx.ParseVersionJSON(`{"platform": "linux_amd64"}`)
+	assert.Error(t, err)
+
+	_, err = testctx.ParseVersionJSON("Terraform v1.9.0")
+	assert.Error(t, err)
+}
+
+func TestContextOutputsUseExecutor(t *testing.T) {
+	ctx := testctx.NewTestContext("example", nil)
+	ctx.Executor = &outputExecutor{outputs: map[string]string{
+		"": `{
+  "name": {"sensitive": false, "type": "string", "value": "example"},
+  "tags": {"sensitive": false, "type": ["map", "string"], "value": {"env": "test", "count": 2}},
+  "regions": {"sensitive": false, "type": ["list", "string"], "value": ["us-east-1", "us-west-2"]}
+}`,
+	}}
+
+	assert.Equal(t, "example", ctx.GetOutput(t, "name"))
+	assert.Equal(t, map[string]string{"env": "test", "count": "2"}, ctx.GetOutputMap(t, "tags"))
+	assert.Equal(t, []string{"us-east-1", "us-west-2"}, ctx.GetOutputList(t, "regions"))
+	assert.Equal(t, map[string]interface{}{
+		"name":    "example",
+		"tags":    map[string]interface{}{"env": "test", "count": float64(2)},
+		"regions": []interface{}{"us-east-1", "us-west-2"},
+	}, ctx.GetAllOutputs(t))
+}
@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// validationFailureJSON is the plan -json output of a failed variable validation
+const validationFailureJSON = `{"@level":"info","@message":"Terraform 1.5.7","type":"version","terraform":"1.5.7","ui":"1.1"}
+{"@level":"error","@message":"Error: Invalid value for variable","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid value for variable","detail":"The bucket name must be lowercase.\n\nThis was checked by the validation rule at variables.tf:4,3-13."}}
+`
+
+// checkWarningJSON is the apply -json output of a failed check block assertion, which does not fail the apply
+const checkWarningJSON = `{"@level":"info","@message":"Apply complete! Resources: 1 added, 0 changed, 0 destroyed.","type":"change_summary"}
+{"@level":"warn","@message":"Warning: Check block assertion failed","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Check block assertion failed","detail":"The website returned status 503.","address":"check.health"}}
+`
+
+func TestParseDiagnostics(t *testing.T) {
+	diagnostics := testctx.ParseDiagnostics(validationFailureJSON + "not json\n" + checkWarningJSON)
+	require.Len(t, diagnostics, 2)
+	assert.Equal(t, testctx.SeverityError, diagnostics[0].Severity)
+	assert.Equal(t, "Invalid value for variable", diagnostics[0].Summary)
+	assert.Equal(t, "check.health", diagnostics[1].Address)
+}
+
+func TestDiagnosticMatcher(t *testing.T) {
+	diagnostics := testctx.ParseDiagnostics(validationFailureJSON + checkWarningJSON)
+
+	matches, err := testctx.DiagnosticMatcher{Detail: "must be lowercase"}.MatchE(diagnostics)
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	// The default severity is error
+	matches, err = testctx.DiagnosticMatcher{Summary: "Check block"}.MatchE(diagnostics)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	matches, err = testctx.DiagnosticMatcher{Severity: "warning", Address: "check.*"}.MatchE(diagnostics)
+	require.NoError(t, err)
+	assert.Len(t, matches, 1)
+
+	_, err = testctx.DiagnosticMatcher{Summary: "("}.MatchE(diagnostics)
+	assert.Error(t, err)
+}
+
+func TestExpectPlanFailure(t *testing.T) {
+	clearStageEnv(t)
+	executor := fake.New().On(fake.CommandPlanJSON, fake.Response{Stdout: validationFailureJSON, ExitCode: 1})
+
+	diagnostics := testctx.ExpectPlanFailure(t, t.TempDir(), fakeConfig(executor), testctx.DiagnosticMatcher{
+		Summary: "Invalid value for variable",
+		Detail:  "must be lowercase",
+	})
+	assert.Len(t, diagnostics, 1)
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandPlanJSON}, executor.Commands())
+}
+
+func TestExpectPlanFailureFails(t *testing.T) {
+	clearStageEnv(t)
+
+	// A plan that succeeds fails the test
+	executor := fake.New()
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.ExpectPlanFailure(ft, t.TempDir(), fakeConfig(executor), testctx.DiagnosticMatcher{})
+	})
+	require.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "but it succeeded")
+
+	// A failure with another diagnostic lists the received diagnostics
+	executor = fake.New().On(fake.CommandPlanJSON, fake.Response{Stdout: validationFailureJSON, ExitCode: 1})
+	ft = fake.Run(t, func(ft *fake.T) {
+		testctx.ExpectPlanFailure(ft, t.TempDir(), fakeConfig(executor), testctx.DiagnosticMatcher{Summary: "Resource precondition failed"})
+	})
+	require.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "error: Invalid value for variable: The bucket name must be lowercase.")
+}
+
+func TestExpectApplyFailure(t *testing.T) {
+	clearStageEnv(t)
+	postconditionJSON := `{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Resource postcondition failed","detail":"Versioning must be enabled.","address":"aws_s3_bucket.this"}}`
+	executor := fake.New().On(fake.CommandApplyJSON, fake.Response{Stdout: postconditionJSON, ExitCode: 1})
+
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.ExpectApplyFailure(ft, t.TempDir(), fakeConfig(executor), testctx.DiagnosticMatcher{
+			Summary: "postcondition",
+			Address: "aws_s3_bucket.*",
+		})
+	})
+	assert.False(t, ft.Failed(), failureMessages(ft))
+	// Partially created resources are destroyed
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApplyJSON, fake.CommandDestroy}, executor.Commands())
+
+	// Warnings such as failed check blocks don't require the apply to fail
+	executor = fake.New().On(fake.CommandApplyJSON, fake.Response{Stdout: checkWarningJSON})
+	ft = fake.Run(t, func(ft *fake.T) {
+		testctx.ExpectApplyFailure(ft, t.TempDir(), fakeConfig(executor), testctx.DiagnosticMatcher{
+			Severity: testctx.SeverityWarning,
+			Summary:  "Check block assertion failed",
+		})
+	})
+	assert.False(t, ft.Failed(), failureMessages(ft))
+}
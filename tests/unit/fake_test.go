@@ -0,0 +1,195 @@
+package unit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// fakeConfig returns a config running an example in place with the given fake executor
+func fakeConfig(executor *fake.Executor) testctx.TestConfig {
+	return testctx.TestConfig{Name: "fake", Workspace: testctx.WorkspaceInPlace, Executor: executor}
+}
+
+// runFakeExample runs RunExample against a fake executor and returns the recording T
+func runFakeExample(t *testing.T, executor *fake.Executor) *fake.T {
+	clearStageEnv(t)
+	examplePath := t.TempDir()
+	return fake.Run(t, func(ft *fake.T) {
+		testctx.RunExample(ft, examplePath, fakeConfig(executor))
+	})
+}
+
+// clearStageEnv unsets the environment variables that change the lifecycle of RunExample
+func clearStageEnv(t *testing.T) {
+	for _, name := range []string{"TERRATEST_IDEMPOTENCY", "TERRATEST_PLAN_ONLY", "TERRATEST_SKIP_STAGES", "TERRATEST_ONLY_STAGES"} {
+		t.Setenv(name, "")
+	}
+}
+
+// failureMessages joins the recorded messages of a fake T
+func failureMessages(ft *fake.T) string {
+	return strings.Join(ft.Messages(), "\n")
+}
+
+func TestRunExampleWithFakeExecutor(t *testing.T) {
+	executor := fake.New()
+	ft := runFakeExample(t, executor)
+
+	assert.False(t, ft.Failed(), failureMessages(ft))
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApply, fake.CommandPlan, fake.CommandDestroy}, executor.Commands())
+}
+
+func TestRunExampleApplyFailure(t *testing.T) {
+	executor := fake.New().On(fake.CommandApply, fake.Response{ExitCode: 1, Error: "Error: creating S3 bucket: AccessDenied"})
+	ft := runFakeExample(t, executor)
+
+	require.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "Failed to apply fake")
+	assert.Contains(t, failureMessages(ft), "AccessDenied")
+	// Partially created resources are still destroyed
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApply, fake.CommandDestroy}, executor.Commands())
+}
+
+func TestRunExampleNonIdempotentPlan(t *testing.T) {
+	executor := fake.Load(t, filepath.Join("testdata", "fake", "non_idempotent"))
+	ft := runFakeExample(t, executor)
+
+	require.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "module.example.local_file.output")
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApply, fake.CommandPlan, fake.CommandShowPlan, fake.CommandDestroy}, executor.Commands())
+}
+
+func TestRunExampleConvergesOnSecondApply(t *testing.T) {
+	clearStageEnv(t)
+	executor := fake.Load(t, filepath.Join("testdata", "fake", "non_idempotent")).
+		On(fake.CommandPlan, fake.Response{ExitCode: testctx.PlanExitCodeNoChanges})
+
+	config := fakeConfig(executor)
+	config.MaxApplies = 2
+	examplePath := t.TempDir()
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.RunExample(ft, examplePath, config)
+	})
+
+	assert.False(t, ft.Failed(), failureMessages(ft))
+	assert.Equal(t, 2, executor.Count(fake.CommandApply))
+	assert.Equal(t, 2, executor.Count(fake.CommandPlan))
+}
+
+func TestRunExampleDestroyFailure(t *testing.T) {
+	executor := fake.New().On(fake.CommandDestroy, fake.Response{Error: "Error: deleting S3 bucket: BucketNotEmpty"})
+	ft := runFakeExample(t, executor)
+
+	require.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "Failed to destroy fake")
+	assert.Contains(t, failureMessages(ft), "BucketNotEmpty")
+}
+
+func TestRunExamplePlanOnlyWithFakeExecutor(t *testing.T) {
+	clearStageEnv(t)
+	executor := fake.Load(t, filepath.Join("testdata", "fake", "non_idempotent"))
+
+	config := fakeConfig(executor)
+	config.PlanOnly = true
+	ctx := testctx.RunExample(t, t.TempDir(), config)
+
+	require.NotNil(t, ctx.Plan)
+	assert.Contains(t, ctx.Plan.ResourceChangesMap, "module.example.local_file.output")
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandPlan, fake.CommandShowPlan}, executor.Commands())
+	assert.NotEmpty(t, executor.Calls()[1].PlanFile, "Plan should be written to a plan file")
+}
+
+func TestRunSingleExamplePlanOnlyWithoutName(t *testing.T) {
+	clearStageEnv(t)
+	t.Setenv("TERRATEST_EXAMPLES", "")
+	executor := fake.Load(t, filepath.Join("testdata", "fake", "non_idempotent"))
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"basic/main.tf": ""})
+
+	// A config without a name keeps its other settings
+	ctx := testctx.RunSingleExample(t, root, "basic", testctx.TestConfig{
+		PlanOnly:  true,
+		Workspace: testctx.WorkspaceInPlace,
+		Executor:  executor,
+	})
+
+	assert.Equal(t, "basic", ctx.Config.Name)
+	assert.NotNil(t, ctx.Config.ExtraVars)
+	assert.Equal(t, 0, executor.Count(fake.CommandApply), "A plan-only config should never apply")
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandPlan, fake.CommandShowPlan}, executor.Commands())
+}
+
+func TestAssertionsWithFakeExecutor(t *testing.T) {
+	executor := fake.Load(t, filepath.Join("testdata", "fake", "outputs"))
+	ctx := testctx.NewTestContext("example", nil)
+	ctx.Executor = executor
+
+	assertions.AssertOutputEquals(t, ctx, "bucket_name", "example-bucket")
+	assertions.AssertOutputListContains(t, ctx, "regions", "us-west-2")
+	assertions.AssertOutputMapKeyEquals(t, ctx, "tags", "env", "test")
+	assertions.AssertResourceCount(t, ctx, "aws_s3_bucket", 2)
+	assertions.AssertTerraformVersion(t, ctx, "1.5.0")
+
+	// Failing assertions are reported without running Terraform
+	mockT := new(MockT)
+	assertions.AssertOutputEquals(mockT, ctx, "bucket_name", "other-bucket")
+	assert.True(t, mockT.Failed())
+
+	// Outputs are read once and cached on the context
+	assert.Equal(t, 1, executor.Count(fake.CommandOutput))
+}
+
+func TestFakeExecutorScript(t *testing.T) {
+	executor := fake.New().On(fake.CommandPlan,
+		fake.Response{ExitCode: testctx.PlanExitCodeChanges},
+		fake.Response{ExitCode: testctx.PlanExitCodeNoChanges},
+	)
+
+	// Responses are served in order and the last one repeats
+	for _, expected := range []int{testctx.PlanExitCodeChanges, testctx.PlanExitCodeNoChanges, testctx.PlanExitCodeNoChanges} {
+		exitCode, err := executor.Plan(t, nil)
+		require.NoError(t, err)
+		assert.Equal(t, expected, exitCode)
+	}
+
+	executor.On(fake.CommandStateList, fake.Response{Stdout: "aws_s3_bucket.a\naws_s3_bucket.b\n"})
+	addresses, err := executor.StateList(t, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws_s3_bucket.a", "aws_s3_bucket.b"}, addresses)
+
+	// Plan exit codes other than 0 and 2 are failures
+	executor = fake.New().On(fake.CommandPlan, fake.Response{ExitCode: 1, Error: "Error: Invalid reference"})
+	_, err = executor.Plan(t, nil)
+	assert.ErrorContains(t, err, "Invalid reference")
+
+	_, err = fake.LoadE(t.TempDir())
+	assert.Error(t, err, "Loading a directory without a script should fail")
+}
+
+func TestFakeRecorderRoundTrip(t *testing.T) {
+	source := fake.Load(t, filepath.Join("testdata", "fake", "outputs"))
+	recorder := fake.NewRecorder(source)
+
+	ctx := testctx.NewTestContext("example", nil)
+	ctx.Executor = recorder
+	assert.Equal(t, "example-bucket", ctx.GetOutput(t, "bucket_name"))
+	_, err := recorder.Destroy(t, ctx.Terraform)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, recorder.Save(dir))
+
+	replay := fake.Load(t, dir)
+	ctx.Executor = replay
+	assert.Equal(t, []string{"us-east-1", "us-west-2"}, ctx.GetOutputList(t, "regions"))
+	_, err = replay.Destroy(t, ctx.Terraform)
+	assert.NoError(t, err)
+}
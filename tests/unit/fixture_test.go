@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// writeFixtureExamples writes empty basic and network examples and returns their examples directory
+func writeFixtureExamples(t *testing.T) string {
+	clearStageEnv(t)
+	t.Setenv("TERRATEST_EXAMPLES", "")
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"basic/main.tf": "", "network/main.tf": ""})
+	return root
+}
+
+func TestDestroySharedFixturesWithoutFixtures(t *testing.T) {
+	assert.NoError(t, testctx.DestroySharedFixtures(), "Destroying an empty registry should succeed")
+}
+
+func TestSharedFixtureAppliedOnce(t *testing.T) {
+	root := writeFixtureExamples(t)
+	executor := fake.New()
+	config := testctx.TestConfig{Workspace: testctx.WorkspaceInPlace, Executor: executor}
+
+	destroy := testctx.ManageSharedFixtures()
+	for i := 0; i < 3; i++ {
+		t.Run(fmt.Sprintf("test-%d", i), func(t *testing.T) {
+			ctx := testctx.SharedFixture(t, root, "basic", config)
+			assert.Equal(t, "basic", ctx.Name)
+		})
+	}
+	testctx.SharedFixture(t, root, "network", config)
+
+	// Every test requesting the fixture shares a single init, apply and idempotency check
+	assert.Equal(t, []string{
+		fake.CommandInit, fake.CommandApply, fake.CommandPlan,
+		fake.CommandInit, fake.CommandApply, fake.CommandPlan,
+	}, executor.Commands())
+
+	// Fixtures are destroyed once each, in reverse order of creation
+	require.NoError(t, destroy())
+	calls := executor.Calls()
+	require.Len(t, calls, 8)
+	assert.Equal(t, fake.CommandDestroy, calls[6].Command)
+	assert.Equal(t, filepath.Join(root, "network"), calls[6].Dir)
+	assert.Equal(t, fake.CommandDestroy, calls[7].Command)
+	assert.Equal(t, filepath.Join(root, "basic"), calls[7].Dir)
+
+	// The registry is empty once destroyed
+	require.NoError(t, testctx.DestroySharedFixtures())
+	assert.Len(t, executor.Calls(), 8)
+}
+
+func TestSharedFixtureSetupFailure(t *testing.T) {
+	root := writeFixtureExamples(t)
+	executor := fake.New().On(fake.CommandApply, fake.Response{ExitCode: 1, Error: "Error: creating S3 bucket: AccessDenied"})
+	config := testctx.TestConfig{Workspace: testctx.WorkspaceInPlace, Executor: executor}
+
+	destroy := testctx.ManageSharedFixtures()
+	for i := 0; i < 2; i++ {
+		ft := fake.Run(t, func(ft *fake.T) {
+			testctx.SharedFixture(ft, root, "basic", config)
+		})
+		require.True(t, ft.Failed(), "Every test requesting the fixture should fail")
+		assert.Contains(t, failureMessages(ft), "Shared fixture basic failed")
+		assert.Contains(t, failureMessages(ft), "AccessDenied")
+	}
+	assert.Equal(t, 1, executor.Count(fake.CommandApply), "A failed setup should not be retried")
+
+	// Partially created resources are destroyed
+	require.NoError(t, destroy())
+	assert.Equal(t, 1, executor.Count(fake.CommandDestroy))
+}
+
+func TestSharedFixtureRequiresRunWithSharedFixtures(t *testing.T) {
+	root := writeFixtureExamples(t)
+	executor := fake.New()
+
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.SharedFixture(ft, root, "basic", testctx.TestConfig{Workspace: testctx.WorkspaceInPlace, Executor: executor})
+	})
+	require.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "requires TestMain to call testctx.RunWithSharedFixtures")
+	assert.Empty(t, executor.Commands())
+}
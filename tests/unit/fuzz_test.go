@@ -0,0 +1,263 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/coverage"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/fuzz"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/tfconfig"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// fuzzExample is an example declaring variables of every kind of type constraint
+var fuzzExample = filepath.Join("testdata", "fuzz")
+
+func TestLoadModuleVariables(t *testing.T) {
+	module, err := tfconfig.LoadModule(fuzzExample)
+	require.NoError(t, err)
+	require.Len(t, module.Variables, 5)
+
+	bucket, ok := module.Variable("bucket_name")
+	require.True(t, ok)
+	assert.Equal(t, cty.String, bucket.Type)
+	assert.True(t, bucket.Required())
+	require.Len(t, bucket.Validations, 1)
+	assert.Equal(t, "The bucket name must be lowercase and at most 63 characters.", bucket.Validations[0].ErrorMessage)
+	assert.Contains(t, bucket.Validations[0].Condition, "length(var.bucket_name) <= 63")
+	require.Len(t, bucket.Validations[0].Literals, 2)
+	assert.True(t, bucket.Validations[0].Literals[0].Equals(cty.NumberIntVal(63)).True())
+	assert.Equal(t, cty.StringVal("^[a-z0-9-]+$"), bucket.Validations[0].Literals[1])
+
+	retention, _ := module.Variable("retention_days")
+	assert.False(t, retention.Required())
+	assert.False(t, retention.Nullable)
+
+	settings, _ := module.Variable("settings")
+	assert.True(t, settings.Type.IsObjectType())
+	assert.True(t, settings.Type.AttributeOptional("prefix"))
+	assert.True(t, settings.Sensitive)
+
+	anything, _ := module.Variable("anything")
+	assert.Equal(t, cty.DynamicPseudoType, anything.Type)
+	assert.False(t, anything.Required(), "A null default is still a default")
+
+	require.Len(t, module.Outputs, 2)
+	assert.Equal(t, tfconfig.Output{Name: "bucket_name", Description: "Name of the bucket", References: []string{"var.bucket_name"}}, module.Outputs[0])
+	assert.True(t, module.Outputs[1].Sensitive)
+
+	names, err := tfconfig.VarsFileNames(filepath.Join(fuzzExample, "terraform.tfvars"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"regions"}, names)
+}
+
+func TestFuzzCases(t *testing.T) {
+	module, err := tfconfig.LoadModule(fuzzExample)
+	require.NoError(t, err)
+	bucket, _ := module.Variable("bucket_name")
+
+	cases := fuzz.Cases(bucket, fuzz.Options{LargeSize: 100})
+	byName := map[string]interface{}{}
+	for _, c := range cases {
+		assert.Equal(t, "bucket_name", c.Variable)
+		byName[c.Name] = c.Value
+	}
+
+	assert.Contains(t, byName, "null")
+	assert.Equal(t, "", byName["empty-string"])
+	assert.Len(t, byName["oversized-string"], 100)
+	assert.Equal(t, []interface{}{"a"}, byName["wrong-type-list"])
+	// Lengths around the limit of the validation condition
+	assert.Len(t, byName["validation-63"], 63)
+	assert.Len(t, byName["validation-64"], 64)
+	assert.Contains(t, byName, "random-3")
+	assert.NotContains(t, byName, "random-4")
+
+	// A negative number disables the random inputs, zero keeps the default
+	for _, c := range fuzz.Cases(bucket, fuzz.Options{RandomCases: -1}) {
+		assert.NotContains(t, c.Name, "random-")
+	}
+
+	// Random inputs are reproducible with the same seed
+	assert.Equal(t, cases, fuzz.Cases(bucket, fuzz.Options{LargeSize: 100}))
+	assert.NotEqual(t, cases, fuzz.Cases(bucket, fuzz.Options{LargeSize: 100, Seed: 42}))
+
+	regions, _ := module.Variable("regions")
+	byName = map[string]interface{}{}
+	for _, c := range fuzz.Cases(regions, fuzz.Options{LargeSize: 10}) {
+		byName[c.Name] = c.Value
+	}
+	assert.Equal(t, []interface{}{}, byName["empty-list"])
+	assert.Len(t, byName["oversized-list"], 10)
+	assert.Equal(t, "not-a-list", byName["wrong-type-string"])
+
+	settings, _ := module.Variable("settings")
+	for _, c := range fuzz.Cases(settings, fuzz.Options{}) {
+		if c.Name == "random-1" {
+			require.IsType(t, map[string]interface{}{}, c.Value)
+			assert.Contains(t, c.Value, "enabled", "Required attributes are always set")
+		}
+	}
+}
+
+func TestFuzzVariablesClassifiesPlans(t *testing.T) {
+	rejected := `{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid value for variable","detail":"The bucket name must be lowercase."}}`
+	unhelpful := `{"type":"diagnostic","diagnostic":{"severity":"error","summary":"Error in function call","detail":"Call to function \"substr\" failed."}}`
+
+	executor := fake.New().On(fake.CommandPlanJSON,
+		fake.Response{},
+		fake.Response{Stdout: rejected, ExitCode: 1},
+		fake.Response{Stdout: unhelpful, ExitCode: 1},
+		fake.Response{ExitCode: 1, Error: "panic: runtime error: index out of range"},
+		fake.Response{},
+	)
+
+	report, err := testctx.FuzzVariablesE(t, fuzzExample, fakeConfig(executor), testctx.FuzzOptions{Variables: []string{"bucket_name"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Count(testctx.FuzzRejected))
+	assert.Equal(t, 1, report.Count(testctx.FuzzUnhelpful))
+	assert.Equal(t, 1, report.Count(testctx.FuzzCrashed))
+	assert.Equal(t, len(report.Results)-3, report.Count(testctx.FuzzAccepted))
+	assert.Equal(t, 1+len(report.Results), executor.Count(fake.CommandPlanJSON), "Every input is planned after the baseline")
+
+	findings := report.Findings()
+	require.Len(t, findings, 2)
+	assert.Equal(t, testctx.FuzzUnhelpful, findings[0].Outcome)
+	assert.Equal(t, "Error in function call", findings[0].Diagnostics[0].Summary)
+	assert.Equal(t, testctx.FuzzCrashed, findings[1].Outcome)
+	assert.Contains(t, report.String(), "CRASHED bucket_name/")
+
+	// FuzzVariables fails the test for each finding
+	executor = fake.New().On(fake.CommandPlanJSON, fake.Response{}, fake.Response{Stdout: unhelpful, ExitCode: 1})
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.FuzzVariables(ft, fuzzExample, fakeConfig(executor), testctx.FuzzOptions{Variables: []string{"regions"}})
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "unhelpful: error: Error in function call")
+}
+
+func TestFuzzVariablesNotRecordedAsCoverage(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TERRATEST_COVERAGE_DIR", dir)
+
+	config := fakeConfig(fake.New())
+	config.ExtraVars = map[string]interface{}{"bucket_name": "example-bucket"}
+	_, err := testctx.FuzzVariablesE(t, fuzzExample, config, testctx.FuzzOptions{Variables: []string{"regions"}})
+	require.NoError(t, err)
+
+	records, err := coverage.ReadRecords(dir)
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestFuzzVariablesBaselineFailure(t *testing.T) {
+	executor := fake.New().On(fake.CommandPlanJSON, fake.Response{ExitCode: 1, Error: "Error: No configuration files"})
+
+	_, err := testctx.FuzzVariablesE(t, fuzzExample, fakeConfig(executor), testctx.FuzzOptions{})
+	assert.ErrorContains(t, err, "plan with the unmodified inputs failed")
+
+	_, err = testctx.FuzzVariablesE(t, fuzzExample, fakeConfig(fake.New()), testctx.FuzzOptions{Variables: []string{"missing"}})
+	assert.ErrorContains(t, err, `variable "missing" is not declared`)
+}
+
+// writeFuzzModule writes a module with an example that forwards one of its variables and transforms the other
+func writeFuzzModule(t *testing.T) string {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"variables.tf": `
+variable "name" {
+  type = string
+  validation {
+    condition     = length(var.name) <= 10
+    error_message = "The name must be at most 10 characters."
+  }
+}
+
+variable "size" {
+  type = number
+}
+`,
+		"examples/basic/main.tf": `
+variable "bucket_name" {
+  type    = string
+  default = "bucket"
+}
+
+variable "count_per_zone" {
+  type    = number
+  default = 1
+}
+
+module "example" {
+  source = "../../"
+  name   = var.bucket_name
+  size   = var.count_per_zone * 2
+}
+`,
+	})
+	return filepath.Join(root, "examples", "basic")
+}
+
+func TestFuzzVariablesOfTheModule(t *testing.T) {
+	examplePath := writeFuzzModule(t)
+	module, err := tfconfig.LoadModule(filepath.Join(examplePath, "..", ".."))
+	require.NoError(t, err)
+	name, _ := module.Variable("name")
+	size, _ := module.Variable("size")
+	nameCases := len(fuzz.Cases(name, fuzz.Options{}))
+	sizeCases := len(fuzz.Cases(size, fuzz.Options{}))
+
+	example, err := tfconfig.LoadModule(examplePath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "bucket_name"}, example.ModuleCalls[0].Forwards)
+
+	executor := fake.New()
+	report, err := testctx.FuzzVariablesE(t, examplePath, fakeConfig(executor), testctx.FuzzOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, report.Skipped)
+	require.Len(t, report.Results, nameCases+sizeCases)
+
+	// The forwarded variable is fuzzed through the example, the transformed one against the module directly
+	for _, result := range report.Results[:nameCases] {
+		assert.Equal(t, "name", result.Variable)
+		assert.Equal(t, "bucket_name", result.Through)
+	}
+	for _, result := range report.Results[nameCases:] {
+		assert.Equal(t, "size", result.Variable)
+		assert.Empty(t, result.Through)
+	}
+
+	var dirs []string
+	for _, call := range executor.Calls() {
+		if call.Command == fake.CommandPlanJSON {
+			dirs = append(dirs, filepath.Clean(call.Dir))
+		}
+	}
+	require.Len(t, dirs, 2+nameCases+sizeCases, "Both the example and the module are planned with unmodified inputs first")
+	assert.Equal(t, filepath.Clean(examplePath), dirs[0])
+	assert.Equal(t, filepath.Clean(examplePath), dirs[nameCases])
+	assert.Equal(t, module.Dir, dirs[nameCases+1])
+	assert.Equal(t, module.Dir, dirs[len(dirs)-1])
+
+	_, err = testctx.FuzzVariablesE(t, examplePath, fakeConfig(fake.New()), testctx.FuzzOptions{Variables: []string{"bucket_name"}})
+	assert.ErrorContains(t, err, `variable "bucket_name" is not declared`, "Variables name the variables of the module")
+}
+
+func TestFuzzVariablesSkipsModuleThatCannotBePlanned(t *testing.T) {
+	examplePath := writeFuzzModule(t)
+	executor := fake.New().On(fake.CommandPlanJSON, fake.Response{}, fake.Response{ExitCode: 1, Error: "Error: Missing required provider"})
+
+	report, err := testctx.FuzzVariablesE(t, examplePath, fakeConfig(executor), testctx.FuzzOptions{Variables: []string{"size"}})
+	require.NoError(t, err)
+	assert.Empty(t, report.Results)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "size", report.Skipped[0].Variable)
+	assert.Contains(t, report.Skipped[0].Reason, "the module cannot be planned on its own")
+	assert.Contains(t, report.String(), "SKIPPED size:")
+}
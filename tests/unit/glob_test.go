@@ -0,0 +1,31 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/glob"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		match   bool
+	}{
+		{"module.example.local_file.output", "module.example.local_file.output", true},
+		{"module.example.*", "module.example.local_file.output", true},
+		{"*.aws_s3_bucket.b[\"logs\"]", "module.x.aws_s3_bucket.b[\"logs\"]", true},
+		{"*.aws_s3_bucket.b[*]", "module.x.aws_s3_bucket.b[\"assets\"]", true},
+		{"tags_all.*", "tags_all.UpdatedAt", true},
+		{"tags_all.*", "tags.UpdatedAt", false},
+		{"output.?", "output.a", true},
+		{"output.?", "output.ab", false},
+		{"module.example", "module.example.local_file.output", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.match, glob.Match(test.pattern, test.value), "pattern %q value %q", test.pattern, test.value)
+	}
+}
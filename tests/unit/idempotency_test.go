@@ -1,15 +1,31 @@
 package unit
 
 import (
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/gruntwork-io/terratest/modules/terraform"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/caylent-solutions/terraform-terratest-framework/internal/idempotency"
 	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
 )
 
+// logRecorder is a testing.TB that keeps the messages logged through it
+type logRecorder struct {
+	testing.TB
+	logs []string
+}
+
+func (r *logRecorder) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+	r.TB.Logf(format, args...)
+}
+
 // TestIdempotencyEnabledDetailed tests the IdempotencyEnabled function
 func TestIdempotencyEnabledDetailed(t *testing.T) {
 	// Test default behavior (enabled)
@@ -37,5 +53,56 @@ func TestIdempotencyPackage(t *testing.T) {
 	assert.NotPanics(t, func() {
 		_ = idempotency.Test
 		_ = idempotency.TestAll
+		_ = idempotency.TestConvergence
 	})
 }
+
+func TestMaxApplies(t *testing.T) {
+	assert.Equal(t, 1, testctx.MaxApplies(testctx.TestConfig{}))
+	assert.Equal(t, 1, testctx.MaxApplies(testctx.TestConfig{MaxApplies: -1}))
+	assert.Equal(t, 3, testctx.MaxApplies(testctx.TestConfig{MaxApplies: 3}))
+}
+
+func TestConvergenceResultString(t *testing.T) {
+	changed := testctx.PlanDiff{Resources: []testctx.ResourceDiff{
+		{Address: "module.example.local_file.output", Actions: []string{"update"}},
+	}}
+
+	converged := testctx.ConvergenceResult{
+		Converged:  true,
+		Applies:    2,
+		MaxApplies: 3,
+		Diffs:      []testctx.PlanDiff{changed, {}},
+	}
+	assert.Contains(t, converged.String(), "Converged after apply 2 of 3")
+	assert.Contains(t, converged.String(), "Plan after apply 1: Terraform plan would change 1 resource(s)")
+	assert.NotContains(t, converged.String(), "Plan after apply 2")
+
+	notConverged := testctx.ConvergenceResult{
+		Applies:    2,
+		MaxApplies: 2,
+		Diffs:      []testctx.PlanDiff{changed, changed},
+	}
+	assert.Contains(t, notConverged.String(), "Did not converge after 2 apply(s)")
+	assert.Contains(t, notConverged.String(), "Plan after apply 2: Terraform plan would change 1 resource(s)")
+}
+
+func TestCheckIdempotencyUnclassifiedChanges(t *testing.T) {
+	executor := fake.New().
+		On(fake.CommandPlan, fake.Response{ExitCode: testctx.PlanExitCodeChanges}).
+		On(fake.CommandShowPlan, fake.Response{Stdout: `{"format_version":"1.2"}`})
+	ctx := testctx.NewTestContext("fake", nil)
+	ctx.Terraform = &terraform.Options{TerraformDir: t.TempDir(), Lock: true}
+	ctx.Executor = executor
+
+	// Terraform reporting changes DiffPlan cannot see does not fail the check, but is logged
+	recorder := &logRecorder{TB: t}
+	diff, err := testctx.CheckIdempotencyE(recorder, ctx)
+	require.NoError(t, err)
+	assert.False(t, diff.HasChanges())
+	assert.Contains(t, strings.Join(recorder.logs, "\n"), "Terraform reported changes for fake that DiffPlan did not classify")
+
+	// The plan runs on a copy of the options, so dropping the state lock does not leak into the context
+	assert.Equal(t, []string{fake.CommandPlan, fake.CommandShowPlan}, executor.Commands())
+	assert.True(t, ctx.Terraform.Lock)
+}
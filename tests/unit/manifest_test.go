@@ -0,0 +1,286 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/examples"
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/manifest"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// fullManifest declares every setting of a tftest.yaml manifest
+const fullManifest = `tags: [smoke, needs-creds]
+timeout: 45m
+vars:
+  name: basic
+  sizes: [1, 2]
+var_files: [prod.tfvars]
+env:
+  AWS_REGION: us-east-1
+skip:
+  plan_only: reads the bucket created by apply
+idempotency:
+  enabled: false
+  max_applies: 2
+  ignore:
+    - address: aws_s3_bucket.*
+      attributes: [tags_all.*]
+expected_outputs:
+  bucket_name: example-bucket
+  count: 2
+depends_on: [network]
+`
+
+func TestManifestSelect(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"examples/basic/tftest.yaml":    "tags: [smoke]\n",
+		"examples/advanced/tftest.yaml": "tags:\n  - slow\n  - needs-creds\n",
+		"examples/eks/main.tf":          "",
+	})
+
+	m, err := manifest.Load(filepath.Join(root, "examples", "advanced"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"slow", "needs-creds"}, m.Tags)
+	assert.True(t, m.HasAnyTag("smoke", "slow"))
+
+	// Examples without a manifest have no tags
+	m, err = manifest.Load(filepath.Join(root, "examples", "eks"))
+	require.NoError(t, err)
+	assert.Empty(t, m.Tags)
+
+	tests := []struct {
+		name     string
+		filter   manifest.Filter
+		expected []string
+	}{
+		{"all", manifest.Filter{}, []string{"advanced", "basic", "eks"}},
+		{"include", manifest.Filter{Include: []string{"eks", "basic"}}, []string{"basic", "eks"}},
+		{"exclude", manifest.Filter{Exclude: []string{"eks"}}, []string{"advanced", "basic"}},
+		{"tags", manifest.Filter{Tags: []string{"smoke", "needs-creds"}}, []string{"advanced", "basic"}},
+		{"tags and exclude", manifest.Filter{Exclude: []string{"basic"}, Tags: []string{"smoke"}}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := manifest.Select(root, tt.filter)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, selected)
+		})
+	}
+
+	_, err = manifest.Select(root, manifest.Filter{Exclude: []string{"missing"}})
+	assert.ErrorContains(t, err, "example missing not found")
+
+	// Dependencies of the selected examples are added and run first, unless excluded
+	writeFiles(t, root, map[string]string{"examples/basic/tftest.yaml": "tags: [smoke]\ndepends_on: [eks]\n"})
+	selected, err := manifest.Select(root, manifest.Filter{Include: []string{"basic"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"eks", "basic"}, selected)
+	selected, err = manifest.Select(root, manifest.Filter{Tags: []string{"smoke"}, Exclude: []string{"eks"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"basic"}, selected)
+
+	writeFiles(t, root, map[string]string{"examples/eks/tftest.yaml": "tags: smoke: [\n"})
+	_, err = manifest.Select(root, manifest.Filter{Tags: []string{"smoke"}})
+	assert.ErrorContains(t, err, "failed to parse")
+}
+
+func TestManifestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"tftest.yaml": fullManifest, "prod.tfvars": ""})
+
+	m, err := manifest.Load(dir)
+	require.NoError(t, err)
+	disabled := false
+	assert.Equal(t, manifest.Manifest{
+		Tags:     []string{"smoke", "needs-creds"},
+		Timeout:  45 * time.Minute,
+		Vars:     map[string]interface{}{"name": "basic", "sizes": []interface{}{1, 2}},
+		VarFiles: []string{"prod.tfvars"},
+		Env:      map[string]string{"AWS_REGION": "us-east-1"},
+		Skip:     manifest.Skip{PlanOnly: "reads the bucket created by apply"},
+		Idempotency: manifest.Idempotency{
+			Enabled:    &disabled,
+			MaxApplies: 2,
+			Ignore:     []manifest.IgnoreRule{{Address: "aws_s3_bucket.*", Attributes: []string{"tags_all.*"}}},
+		},
+		ExpectedOutputs: map[string]interface{}{"bucket_name": "example-bucket", "count": 2},
+		DependsOn:       []string{"network"},
+	}, m)
+
+	invalid := map[string]string{
+		"unknown field":    "tag: [smoke]\n",
+		"invalid timeout":  "timeout: soon\n",
+		"negative applies": "idempotency:\n  max_applies: -1\n",
+		"missing var file": "var_files: [missing.tfvars]\n",
+		"missing address":  "idempotency:\n  ignore:\n    - attributes: [tags]\n",
+	}
+	for name, content := range invalid {
+		writeFiles(t, dir, map[string]string{"tftest.yaml": content})
+		_, err := manifest.Load(dir)
+		assert.Error(t, err, name)
+	}
+
+	// An empty manifest is valid
+	writeFiles(t, dir, map[string]string{"tftest.yaml": ""})
+	_, err = manifest.Load(dir)
+	assert.NoError(t, err)
+}
+
+func TestManifestOrder(t *testing.T) {
+	ordered, err := manifest.Order([]string{"app", "db", "network"}, map[string][]string{
+		"app": {"db", "network"},
+		"db":  {"network", "external"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"network", "db", "app"}, ordered)
+
+	_, err = manifest.Order([]string{"a", "b"}, map[string][]string{"a": {"b"}, "b": {"a"}})
+	assert.ErrorContains(t, err, "dependency cycle: a -> b -> a")
+
+	// Stages group the examples whose dependencies ran in earlier stages
+	stages, err := manifest.Stages([]string{"app", "db", "network", "cache"}, map[string][]string{
+		"app":   {"db", "cache"},
+		"db":    {"network"},
+		"cache": {"external"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"network", "cache"}, {"db"}, {"app"}}, stages)
+}
+
+func TestApplyManifest(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"tftest.yaml": fullManifest, "prod.tfvars": ""})
+	m, err := testctx.LoadManifest(dir)
+	require.NoError(t, err)
+
+	// Settings made in Go take precedence over the manifest
+	config := testctx.ApplyManifest(testctx.TestConfig{
+		ExtraVars:  map[string]interface{}{"name": "override"},
+		EnvVars:    map[string]string{"AWS_REGION": "eu-west-1"},
+		VarFiles:   []string{"extra.tfvars"},
+		MaxApplies: 3,
+	}, m)
+	assert.Equal(t, map[string]interface{}{"name": "override", "sizes": []interface{}{1, 2}}, config.ExtraVars)
+	assert.Equal(t, map[string]string{"AWS_REGION": "eu-west-1"}, config.EnvVars)
+	assert.Equal(t, []string{"prod.tfvars", "extra.tfvars"}, config.VarFiles)
+	assert.Equal(t, 3, config.MaxApplies)
+	assert.Equal(t, 45*time.Minute, config.Timeout)
+	assert.True(t, config.DisableIdempotency)
+	assert.Len(t, config.IdempotencyIgnore, 1)
+
+	// Applying the manifest again changes nothing
+	assert.Equal(t, config, testctx.ApplyManifest(config, m))
+}
+
+func TestRunExampleWithManifest(t *testing.T) {
+	clearStageEnv(t)
+	examplePath := t.TempDir()
+	writeFiles(t, examplePath, map[string]string{"tftest.yaml": fullManifest, "prod.tfvars": ""})
+
+	executor := fake.New().On(fake.CommandOutput, fake.Response{
+		Stdout: `{"bucket_name": {"value": "example-bucket"}, "count": {"value": 3}}`,
+	})
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.RunExample(ft, examplePath, fakeConfig(executor))
+	})
+
+	// The idempotency check is disabled and the outputs are compared with the expected outputs
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApply, fake.CommandOutput, fake.CommandDestroy}, executor.Commands())
+	apply := executor.Calls()[1]
+	assert.Equal(t, map[string]interface{}{"name": "basic", "sizes": []interface{}{1, 2}}, apply.Vars)
+	assert.Equal(t, []string{"prod.tfvars"}, apply.VarFiles)
+	assert.Equal(t, map[string]string{"AWS_REGION": "us-east-1"}, apply.EnvVars)
+
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "output count of fake does not match the expected output:\n  expected: 2\n  actual:   3")
+	assert.NotContains(t, failureMessages(ft), "bucket_name")
+
+	// The example is skipped in plan-only mode with the reason of the manifest
+	t.Setenv("TERRATEST_PLAN_ONLY", "true")
+	ft = fake.Run(t, func(ft *fake.T) {
+		testctx.RunExample(ft, examplePath, fakeConfig(fake.New()))
+	})
+	assert.True(t, ft.Skipped())
+	assert.Contains(t, failureMessages(ft), "reads the bucket created by apply")
+}
+
+func TestFindAllExamplesWithManifest(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"examples/app/tftest.yaml":     "depends_on: [network]\nvars:\n  name: app\n",
+		"examples/network/tftest.yaml": "tags: [smoke]\n",
+	})
+
+	found := examples.FindAllExamples(t, root)
+	require.Len(t, found, 2)
+	assert.Equal(t, "network", found[0].Name, "Dependencies should come first")
+	assert.Equal(t, []string{"smoke"}, found[0].Manifest.Tags)
+	assert.Equal(t, map[string]interface{}{"name": "app"}, found[1].Config.ExtraVars)
+
+	writeFiles(t, root, map[string]string{"examples/example-app/main.tf": "", "examples/example-db/tftest.yaml": "depends_on: [example-app]\n"})
+	assert.Equal(t, []string{"example-app", "example-db"}, testctx.DiscoverExamples(t, filepath.Join(root, "examples")))
+}
+
+func TestRunnersApplyManifest(t *testing.T) {
+	clearStageEnv(t)
+	t.Setenv("TERRATEST_EXAMPLES", "")
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"basic/tftest.yaml":  fullManifest,
+		"basic/prod.tfvars":  "",
+		"fuzzed/main.tf":     "variable \"name\" {\n  type = string\n}\n",
+		"fuzzed/tftest.yaml": "var_files: [prod.tfvars]\nenv:\n  AWS_REGION: us-east-1\n",
+		"fuzzed/prod.tfvars": "name = \"prod\"\n",
+	})
+	examplePath := filepath.Join(root, "basic")
+	expectedVars := map[string]interface{}{"name": "basic", "sizes": []interface{}{1, 2}}
+
+	// Shared fixtures apply with the manifest and return the expected output mismatch to the requesting test
+	executor := fake.New().On(fake.CommandOutput, fake.Response{
+		Stdout: `{"bucket_name": {"value": "example-bucket"}, "count": {"value": 3}}`,
+	})
+	destroy := testctx.ManageSharedFixtures()
+	ft := fake.Run(t, func(ft *fake.T) {
+		testctx.SharedFixture(ft, root, "basic", fakeConfig(executor))
+	})
+	require.NoError(t, destroy())
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "output count of fake does not match the expected output")
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApply, fake.CommandOutput, fake.CommandDestroy}, executor.Commands())
+	apply := executor.Calls()[1]
+	assert.Equal(t, expectedVars, apply.Vars)
+	assert.Equal(t, []string{"prod.tfvars"}, apply.VarFiles)
+	assert.Equal(t, map[string]string{"AWS_REGION": "us-east-1"}, apply.EnvVars)
+
+	// Expected failures plan with the vars, var files and env of the manifest
+	executor = fake.New().On(fake.CommandPlanJSON, fake.Response{Stdout: validationFailureJSON, ExitCode: 1})
+	testctx.ExpectPlanFailure(t, examplePath, fakeConfig(executor), testctx.DiagnosticMatcher{Summary: "Invalid value for variable"})
+	plan := executor.Calls()[1]
+	assert.Equal(t, expectedVars, plan.Vars)
+	assert.Equal(t, []string{"prod.tfvars"}, plan.VarFiles)
+	assert.Equal(t, map[string]string{"AWS_REGION": "us-east-1"}, plan.EnvVars)
+
+	// Fuzzing only plans, so examples that cannot be planned without an apply are skipped
+	ft = fake.Run(t, func(ft *fake.T) {
+		testctx.FuzzVariables(ft, examplePath, fakeConfig(fake.New()), testctx.FuzzOptions{})
+	})
+	assert.True(t, ft.Skipped())
+	assert.Contains(t, failureMessages(ft), "reads the bucket created by apply")
+
+	// Fuzzed plans add the inputs to the var files and env of the manifest
+	executor = fake.New()
+	_, err := testctx.FuzzVariablesE(t, filepath.Join(root, "fuzzed"), fakeConfig(executor), testctx.FuzzOptions{})
+	require.NoError(t, err)
+	baseline := executor.Calls()[1]
+	require.Equal(t, fake.CommandPlanJSON, baseline.Command)
+	require.Len(t, baseline.VarFiles, 2)
+	assert.Equal(t, "prod.tfvars", baseline.VarFiles[0])
+	assert.Equal(t, map[string]string{"AWS_REGION": "us-east-1"}, baseline.EnvVars)
+}
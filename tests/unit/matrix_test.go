@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/matrix"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+func TestVersionedName(t *testing.T) {
+	t.Setenv("TERRATEST_TERRAFORM_VERSION", "")
+	assert.Equal(t, "basic", testctx.VersionedName("basic"))
+
+	t.Setenv("TERRATEST_TERRAFORM_VERSION", "v1.5.7")
+	assert.Equal(t, "basic@1.5.7", testctx.VersionedName("basic"))
+
+	assert.Equal(t, "basic@1.5.7", testctx.VersionedName("basic@1.5.7"), "An already suffixed name is kept")
+	assert.Empty(t, testctx.VersionedName(""))
+
+	// Each version persists its own context
+	assert.Equal(t, filepath.Join("examples", "basic", ".tftest", "basic_1.5.7.json"), testctx.ContextFile(filepath.Join("examples", "basic"), "basic"))
+	assert.Equal(t, filepath.Join("examples", "basic", ".tftest", "basic_1.5.7.json"), testctx.ContextFile(filepath.Join("examples", "basic"), "basic@1.5.7"))
+}
+
+func TestRunExampleVersionedName(t *testing.T) {
+	clearStageEnv(t)
+	t.Setenv("TERRATEST_TERRAFORM_VERSION", "1.5.7")
+
+	// The runners suffix the context name themselves, so tests do not need to wrap their names
+	ctx := testctx.RunExample(t, t.TempDir(), fakeConfig(fake.New()))
+	assert.Equal(t, "fake@1.5.7", ctx.Name)
+
+	examplesDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(examplesDir, "basic"), 0755))
+	ctx = testctx.RunSingleExample(t, examplesDir, "basic", testctx.TestConfig{Workspace: testctx.WorkspaceInPlace, Executor: fake.New()})
+	assert.Equal(t, "basic@1.5.7", ctx.Name)
+}
+
+func TestFindCachedBinary(t *testing.T) {
+	cacheDir := t.TempDir()
+	writeFiles(t, cacheDir, map[string]string{
+		"1.5.7/terraform":  "#!/bin/sh",
+		"terraform_1.9.0":  "#!/bin/sh",
+		"terraform-1.10.2": "#!/bin/sh",
+	})
+
+	for version, expected := range map[string]string{
+		"1.5.7":  filepath.Join(cacheDir, "1.5.7", "terraform"),
+		"v1.9.0": filepath.Join(cacheDir, "terraform_1.9.0"),
+		"1.10.2": filepath.Join(cacheDir, "terraform-1.10.2"),
+	} {
+		path, err := testctx.FindCachedBinary(cacheDir, "", version)
+		require.NoError(t, err, version)
+		assert.Equal(t, expected, path)
+	}
+
+	_, err := testctx.FindCachedBinary(cacheDir, "terraform", "1.6.0")
+	assert.ErrorContains(t, err, "terraform 1.6.0 not found")
+}
+
+func TestReadVersionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), testctx.VersionsFile)
+	require.NoError(t, os.WriteFile(path, []byte("# Supported versions\n1.5.7\n\nv1.9.0, 1.10.2\n"), 0644))
+
+	versions, err := testctx.ReadVersionsFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.5.7", "1.9.0", "1.10.2"}, versions)
+
+	assert.Equal(t, []string{"1.5.7", "1.9.0"}, testctx.ParseVersions(" 1.5.7,,v1.9.0 "))
+}
+
+func TestVersionMatrix(t *testing.T) {
+	results := matrix.New([]string{"1.5.7", "1.9.0"})
+
+	require.NoError(t, results.RecordOutput("1.5.7", strings.NewReader(`=== RUN   TestBasicOutput
+--- PASS: TestBasicOutput (1.00s)
+PASS
+ok  	github.com/org/module/tests/basic	1.2s
+--- FAIL: TestAdvancedOutput (1.00s)
+FAIL
+FAIL	github.com/org/module/tests/advanced	1.5s
+?   	github.com/org/module/tests/helpers	[no test files]
+`)))
+	require.NoError(t, results.RecordOutput("1.9.0", strings.NewReader(`ok  	github.com/org/module/tests/basic	1.1s
+ok  	github.com/org/module/tests/advanced	(cached)
+`)))
+
+	assert.True(t, results.Failed())
+	assert.Equal(t, []string{"advanced", "basic"}, results.Examples())
+	assert.Equal(t, matrix.StatusFail, results.Status("1.5.7", "advanced"))
+	assert.Equal(t, matrix.StatusPass, results.Status("1.9.0", "advanced"))
+	assert.Equal(t, matrix.StatusNone, results.Status("1.9.0", "helpers"))
+	assert.Equal(t, `EXAMPLE   1.5.7  1.9.0
+advanced  FAIL   PASS
+basic     PASS   PASS`, results.String())
+
+	// A failure of any package of an example is kept
+	results.Record("1.9.0", "basic", matrix.StatusFail)
+	results.Record("1.9.0", "basic", matrix.StatusPass)
+	assert.Equal(t, matrix.StatusFail, results.Status("1.9.0", "basic"))
+
+	assert.Equal(t, "common", matrix.ExampleName("github.com/org/module/tests/common/nested"))
+}
@@ -0,0 +1,176 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// decodeOutputsJSON holds outputs of every type for the decoding tests
+const decodeOutputsJSON = `{
+  "bucket_name": {"sensitive": false, "type": "string", "value": "example-bucket"},
+  "json_data": {
+    "sensitive": false,
+    "type": ["object", {"message": "string", "enabled": "bool", "retries": "number"}],
+    "value": {"message": "advanced", "enabled": true, "retries": 3, "settings": {"regions": ["us-east-1"]}}
+  },
+  "instance_count": {"sensitive": false, "type": "number", "value": 2},
+  "region": {"sensitive": false, "type": "string", "value": "us-east-1"}
+}`
+
+// outputsContext returns a context whose fake executor serves decodeOutputsJSON
+func outputsContext() (testctx.TestContext, *fake.Executor) {
+	executor := fake.New().On(fake.CommandOutput, fake.Response{Stdout: decodeOutputsJSON})
+	ctx := testctx.NewTestContext("example", nil)
+	ctx.Executor = executor
+	return ctx, executor
+}
+
+type jsonData struct {
+	Message  string `json:"message"`
+	Enabled  bool   `json:"enabled"`
+	Retries  int    `json:"retries"`
+	Settings struct {
+		Regions []string `json:"regions"`
+	} `json:"settings"`
+}
+
+func TestDecodeOutput(t *testing.T) {
+	ctx, _ := outputsContext()
+
+	var data jsonData
+	ctx.DecodeOutput(t, "json_data", &data)
+	assert.Equal(t, "advanced", data.Message)
+	assert.True(t, data.Enabled)
+	assert.Equal(t, 3, data.Retries)
+	assert.Equal(t, []string{"us-east-1"}, data.Settings.Regions)
+
+	var count int
+	ctx.DecodeOutput(t, "instance_count", &count)
+	assert.Equal(t, 2, count)
+}
+
+func TestDecodeOutputErrors(t *testing.T) {
+	ctx, _ := outputsContext()
+
+	var wrong struct {
+		Settings struct {
+			Regions string `json:"regions"`
+		} `json:"settings"`
+	}
+	err := ctx.DecodeOutputE(t, "json_data", &wrong)
+	assert.EqualError(t, err, `output "json_data": field settings.regions: cannot decode array into string`)
+
+	var name int
+	err = ctx.DecodeOutputE(t, "bucket_name", &name)
+	assert.EqualError(t, err, `output "bucket_name": cannot decode string into int`)
+
+	err = ctx.DecodeOutputE(t, "missing", &name)
+	assert.EqualError(t, err, `output "missing" not found, available outputs: bucket_name, instance_count, json_data, region`)
+
+	// DecodeOutput fails the test
+	ft := fake.Run(t, func(ft *fake.T) {
+		ctx.DecodeOutput(ft, "bucket_name", &name)
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), `output "bucket_name"`)
+}
+
+func TestDecodeAllOutputs(t *testing.T) {
+	ctx, _ := outputsContext()
+
+	var outputs struct {
+		Bucket   string   `output:"bucket_name"`
+		Data     jsonData `output:"json_data"`
+		Count    int      `output:"instance_count"`
+		Region   string
+		Optional string `output:"optional_output,optional"`
+		Ignored  string `output:"-"`
+	}
+	ctx.DecodeAllOutputs(t, &outputs)
+	assert.Equal(t, "example-bucket", outputs.Bucket)
+	assert.Equal(t, "advanced", outputs.Data.Message)
+	assert.Equal(t, 2, outputs.Count)
+	assert.Equal(t, "us-east-1", outputs.Region, "Fields without a tag should match outputs by name")
+	assert.Empty(t, outputs.Optional)
+
+	// CamelCase field names fall back to the snake_case output name
+	var camelCase struct {
+		BucketName    string
+		JSONData      jsonData
+		InstanceCount int
+	}
+	ctx.DecodeAllOutputs(t, &camelCase)
+	assert.Equal(t, "example-bucket", camelCase.BucketName)
+	assert.Equal(t, "advanced", camelCase.JSONData.Message)
+	assert.Equal(t, 2, camelCase.InstanceCount)
+
+	var missing struct {
+		Bucket int    `output:"bucket_name"`
+		Other  string `output:"other"`
+	}
+	err := ctx.DecodeAllOutputsE(t, &missing)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `output "bucket_name": cannot decode string into int`)
+	assert.Contains(t, err.Error(), `output "other" for field Other not found`)
+
+	assert.Error(t, ctx.DecodeAllOutputsE(t, missing), "Decoding into a non-pointer should fail")
+}
+
+func TestOutputsAreCached(t *testing.T) {
+	ctx, executor := outputsContext()
+
+	ctx.GetOutput(t, "bucket_name")
+	ctx.GetAllOutputs(t)
+	var data jsonData
+	ctx.DecodeOutput(t, "json_data", &data)
+
+	// Copies of the context share the cache
+	copied := ctx
+	copied.GetOutput(t, "instance_count")
+	assert.Equal(t, 1, executor.Count(fake.CommandOutput))
+
+	ctx.ResetOutputs()
+	ctx.GetOutput(t, "bucket_name")
+	assert.Equal(t, 2, executor.Count(fake.CommandOutput))
+}
+
+// plannedOutputsJSON is a plan with a known output and one only known after apply
+const plannedOutputsJSON = `{
+  "format_version": "1.2",
+  "planned_values": {
+    "outputs": {
+      "output_content": {"sensitive": false, "value": "hello from basic"},
+      "creation_timestamp": {"sensitive": false}
+    },
+    "root_module": {}
+  },
+  "output_changes": {
+    "output_content": {"actions": ["create"], "before": null, "after": "hello from basic", "after_unknown": false},
+    "creation_timestamp": {"actions": ["create"], "before": null, "after_unknown": true}
+  }
+}`
+
+func TestOutputsInPlanOnlyMode(t *testing.T) {
+	plan, err := terraform.ParsePlanJSON(plannedOutputsJSON)
+	require.NoError(t, err)
+
+	executor := fake.New()
+	ctx := testctx.NewTestContext("example", nil)
+	ctx.Executor = executor
+	ctx.Plan = plan
+
+	// Outputs known before apply are read from the plan instead of running terraform output
+	assert.Equal(t, "hello from basic", ctx.GetOutput(t, "output_content"))
+	assertions.AssertOutputEquals(t, ctx, "output_content", "hello from basic")
+	assert.Equal(t, 0, executor.Count(fake.CommandOutput))
+
+	_, err = ctx.OutputE(t, "creation_timestamp")
+	assert.ErrorContains(t, err, `output "creation_timestamp" is not known before apply in plan-only mode`)
+}
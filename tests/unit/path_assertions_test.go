@@ -0,0 +1,131 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/jsonpath"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// pathOutputsJSON holds a structured output and the same data as a JSON-encoded string output
+const pathOutputsJSON = `{
+  "json_data": {
+    "sensitive": false,
+    "type": ["object", {}],
+    "value": {"regions": ["us-east-1", "us-west-2"], "settings": {"retries": 3, "tag name": "a.b"}}
+  },
+  "json_string": {
+    "sensitive": false,
+    "type": "string",
+    "value": "{\"regions\": [\"us-east-1\", \"us-west-2\"], \"settings\": {\"retries\": 3}}"
+  }
+}`
+
+// pathContext returns a context whose fake executor serves pathOutputsJSON
+func pathContext() testctx.TestContext {
+	ctx := testctx.NewTestContext("example", nil)
+	ctx.Executor = fake.New().On(fake.CommandOutput, fake.Response{Stdout: pathOutputsJSON})
+	return ctx
+}
+
+func TestJSONPathParse(t *testing.T) {
+	segments, err := jsonpath.Parse(`$.settings['tag name'][0]["x]y"].last[-1]`)
+	require.NoError(t, err)
+	assert.Equal(t, []jsonpath.Segment{
+		{Name: "settings"},
+		{Name: "tag name"},
+		{Index: 0, IsIndex: true},
+		{Name: "x]y"},
+		{Name: "last"},
+		{Index: -1, IsIndex: true},
+	}, segments)
+
+	segments, err = jsonpath.Parse("regions[0]")
+	require.NoError(t, err)
+	assert.Equal(t, []jsonpath.Segment{{Name: "regions"}, {Index: 0, IsIndex: true}}, segments)
+
+	segments, err = jsonpath.Parse("$")
+	require.NoError(t, err)
+	assert.Empty(t, segments)
+
+	for _, invalid := range []string{"$.a[", "$.a[x]", "$..a", "$.a['b]", "$a"} {
+		_, err := jsonpath.Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestJSONPathResolve(t *testing.T) {
+	value := map[string]interface{}{
+		"regions":  []interface{}{"us-east-1", "us-west-2"},
+		"settings": `{"retries": 3}`,
+	}
+
+	resolved, err := jsonpath.Resolve(value, "$.regions[-1]")
+	require.NoError(t, err)
+	assert.Equal(t, "us-west-2", resolved)
+
+	// JSON strings are decoded when the path continues into them
+	resolved, err = jsonpath.Resolve(value, "$.settings.retries")
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), resolved)
+
+	_, err = jsonpath.Resolve(value, "$.regions[2]")
+	var resolveErr *jsonpath.ResolveError
+	require.ErrorAs(t, err, &resolveErr)
+	assert.Equal(t, "$.regions", resolveErr.Resolved)
+	assert.EqualError(t, err, `path $.regions[2] not found: index 2 out of range (length 2) at $.regions, which is ["us-east-1","us-west-2"]`)
+
+	_, err = jsonpath.Resolve(value, "$.settings.timeout")
+	assert.EqualError(t, err, `path $.settings.timeout not found: no key "timeout" at $.settings, which is {"retries":3}`)
+}
+
+func TestAssertOutputPath(t *testing.T) {
+	ctx := pathContext()
+
+	for _, output := range []string{"json_data", "json_string"} {
+		assertions.AssertOutputPathEquals(t, ctx, output, "$.regions[0]", "us-east-1")
+		assertions.AssertOutputPathEquals(t, ctx, output, "$.regions", []string{"us-east-1", "us-west-2"})
+		assertions.AssertOutputPathEquals(t, ctx, output, "$.settings.retries", 3)
+		assertions.AssertOutputPathExists(t, ctx, output, "$.settings")
+		assertions.AssertOutputPathMatches(t, ctx, output, "$.regions[1]", `^us-west-\d$`)
+		assertions.AssertOutputPathMatches(t, ctx, output, "$.settings", `"retries":3`)
+	}
+	assertions.AssertOutputPathEquals(t, ctx, "json_data", "$.settings['tag name']", "a.b")
+}
+
+func TestAssertOutputPathFailures(t *testing.T) {
+	ctx := pathContext()
+
+	ft := fake.Run(t, func(ft *fake.T) {
+		assertions.AssertOutputPathEquals(ft, ctx, "json_data", "$.regions", []string{"eu-west-1"})
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), `got ["us-east-1","us-west-2"]`)
+
+	// Failures show the resolved subtree
+	ft = fake.Run(t, func(ft *fake.T) {
+		assertions.AssertOutputPathExists(ft, ctx, "json_string", "$.settings.timeout")
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), `no key "timeout" at $.settings, which is {"retries":3}`)
+
+	mockT := new(MockT)
+	assertions.AssertOutputPathMatches(mockT, ctx, "json_data", "$.regions[0]", "^eu-")
+	assert.True(t, mockT.Failed())
+
+	// An invalid regex fails the assertion instead of panicking
+	ft = fake.Run(t, func(ft *fake.T) {
+		assertions.AssertOutputPathMatches(ft, ctx, "json_data", "$.regions[0]", "([")
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "Regex should be valid")
+
+	mockT = new(MockT)
+	assertions.AssertOutputPathExists(mockT, ctx, "missing", "$.regions")
+	assert.True(t, mockT.Failed())
+}
@@ -0,0 +1,97 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions/plan"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+)
+
+// planContext builds a test context from a recorded plan fixture
+func planContext(t *testing.T, fixture string) testctx.TestContext {
+	return testctx.TestContext{
+		Name: fixture,
+		Plan: plan.LoadPlanFile(t, "testdata/plans/"+fixture),
+	}
+}
+
+func TestAssertPlanCreates(t *testing.T) {
+	ctx := planContext(t, "create.json")
+
+	mockT := &MockT{}
+	plan.AssertPlanCreates(mockT, ctx, "module.example.local_file.output")
+	assert.False(t, mockT.Failed(), "Create should be detected: %v", mockT.Messages)
+
+	mockT = &MockT{}
+	plan.AssertPlanCreates(mockT, ctx, "module.example.local_file.missing")
+	assert.True(t, mockT.Failed(), "Missing resource should fail")
+
+	mockT = &MockT{}
+	plan.AssertPlanCreates(mockT, planContext(t, "replace.json"), "module.example.time_static.creation_time")
+	assert.True(t, mockT.Failed(), "Delete should not count as create")
+
+	// A resource change without planned actions fails instead of panicking
+	ctx.Plan.ResourceChangesMap["module.example.local_file.output"].Change = nil
+	mockT = &MockT{}
+	assert.NotPanics(t, func() {
+		plan.AssertPlanCreates(mockT, ctx, "module.example.local_file.output")
+	})
+	assert.True(t, mockT.Failed(), "A change without actions should fail")
+}
+
+func TestAssertPlanDestroysNothing(t *testing.T) {
+	mockT := &MockT{}
+	plan.AssertPlanDestroysNothing(mockT, planContext(t, "create.json"))
+	assert.False(t, mockT.Failed(), "Create-only plan should pass: %v", mockT.Messages)
+
+	mockT = &MockT{}
+	plan.AssertPlanDestroysNothing(mockT, planContext(t, "replace.json"))
+	assert.True(t, mockT.Failed(), "Plan with deletes should fail")
+}
+
+func TestAssertNoReplacements(t *testing.T) {
+	mockT := &MockT{}
+	plan.AssertNoReplacements(mockT, planContext(t, "create.json"))
+	assert.False(t, mockT.Failed(), "Create-only plan should pass: %v", mockT.Messages)
+
+	mockT = &MockT{}
+	plan.AssertNoReplacements(mockT, planContext(t, "replace.json"))
+	assert.True(t, mockT.Failed(), "Plan with replacements should fail")
+}
+
+func TestAssertPlannedAttributeEquals(t *testing.T) {
+	ctx := planContext(t, "create.json")
+
+	mockT := &MockT{}
+	plan.AssertPlannedAttributeEquals(mockT, ctx, "module.example.local_file.output", "content", "Hello, World!")
+	plan.AssertPlannedAttributeEquals(mockT, ctx, "module.example.time_static.creation_time", "triggers.file_content_hash",
+		"dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f")
+	assert.False(t, mockT.Failed(), "Planned attributes should match: %v", mockT.Messages)
+
+	mockT = &MockT{}
+	plan.AssertPlannedAttributeEquals(mockT, ctx, "module.example.local_file.output", "file_permission", "0600")
+	assert.True(t, mockT.Failed(), "Different value should fail")
+
+	mockT = &MockT{}
+	plan.AssertPlannedAttributeEquals(mockT, ctx, "module.example.local_file.output", "id", "unknown")
+	assert.True(t, mockT.Failed(), "Unknown attribute should fail")
+}
+
+func TestAssertPlanResourceCount(t *testing.T) {
+	mockT := &MockT{}
+	plan.AssertPlanResourceCount(mockT, planContext(t, "create.json"), 2)
+	plan.AssertPlanResourceCount(mockT, planContext(t, "replace.json"), 1)
+	assert.False(t, mockT.Failed(), "Resource counts should match: %v", mockT.Messages)
+
+	mockT = &MockT{}
+	plan.AssertPlanResourceCount(mockT, planContext(t, "create.json"), 3)
+	assert.True(t, mockT.Failed(), "Wrong count should fail")
+}
+
+func TestPlanAssertionsWithoutPlan(t *testing.T) {
+	mockT := &MockT{}
+	plan.AssertPlanDestroysNothing(mockT, testctx.TestContext{Name: "no-plan"})
+	assert.True(t, mockT.Failed(), "Missing plan should fail")
+}
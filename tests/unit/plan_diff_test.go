@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions/plan"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+)
+
+func TestDiffPlanUpdate(t *testing.T) {
+	diff := testctx.DiffPlan(plan.LoadPlanFile(t, "testdata/plans/update.json"))
+
+	require.True(t, diff.HasChanges())
+	assert.Equal(t, []string{"module.example.local_file.output", "output.output_content"}, diff.Addresses(),
+		"No-op and read changes should be ignored")
+
+	resource := diff.Resources[0]
+	assert.Equal(t, []string{"update"}, resource.Actions)
+
+	attributes := map[string]testctx.AttributeDiff{}
+	for _, attribute := range resource.Attributes {
+		attributes[attribute.Path] = attribute
+	}
+	assert.Len(t, attributes, 4)
+	assert.Equal(t, "Hello, Terraform!", attributes["content"].After)
+	assert.True(t, attributes["content_sha256"].Unknown)
+	assert.True(t, attributes["password"].Sensitive)
+	assert.Equal(t, "2026-01-02", attributes["tags.UpdatedAt"].After)
+	assert.NotContains(t, attributes, "filename", "Unchanged attributes should not be reported")
+
+	report := diff.String()
+	assert.Contains(t, report, "module.example.local_file.output (update)")
+	assert.Contains(t, report, `content: "Hello, World!" => "Hello, Terraform!"`)
+	assert.Contains(t, report, "content_sha256: \"dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f\" => (known after apply)")
+	assert.Contains(t, report, "password: (sensitive value) => (sensitive value)")
+	assert.NotContains(t, report, "new-secret")
+}
+
+func TestDiffPlanReplace(t *testing.T) {
+	diff := testctx.DiffPlan(plan.LoadPlanFile(t, "testdata/plans/replace.json"))
+
+	require.Len(t, diff.Resources, 2)
+	assert.Equal(t, []string{"delete", "create"}, diff.Resources[0].Actions)
+	assert.NotEmpty(t, diff.Resources[0].Attributes, "Replacements should report differing attributes")
+	assert.Equal(t, []string{"delete"}, diff.Resources[1].Actions)
+	assert.Empty(t, diff.Resources[1].Attributes, "Deletes should only report the action")
+}
+
+func TestDiffPlanNoChanges(t *testing.T) {
+	diff := testctx.DiffPlan(nil)
+	assert.False(t, diff.HasChanges())
+	assert.Equal(t, "No changes", diff.String())
+}
+
+func TestPlanDiffFilterIgnored(t *testing.T) {
+	diff := testctx.DiffPlan(plan.LoadPlanFile(t, "testdata/plans/update.json"))
+
+	// Ignore the output entirely and only some attributes of the resource
+	filtered := diff.FilterIgnored([]testctx.IdempotencyIgnoreRule{
+		{Address: "output.*"},
+		{Address: "module.example.local_file.*", Attributes: []string{"tags.*", "content_sha256"}},
+	})
+
+	require.True(t, filtered.HasChanges(), "Drift outside the ignore list should still be reported")
+	assert.Equal(t, []string{"module.example.local_file.output"}, filtered.Addresses())
+	for _, attribute := range filtered.Resources[0].Attributes {
+		assert.NotEqual(t, "tags.UpdatedAt", attribute.Path)
+		assert.NotEqual(t, "content_sha256", attribute.Path)
+	}
+
+	require.Len(t, filtered.Ignored, 2)
+	assert.Contains(t, filtered.IgnoredString(), "output.output_content")
+	assert.Contains(t, filtered.IgnoredString(), "tags.UpdatedAt")
+
+	// Ignoring every differing attribute makes the plan idempotent
+	filtered = diff.FilterIgnored([]testctx.IdempotencyIgnoreRule{
+		{Address: "output.output_content"},
+		{Address: "module.example.local_file.output", Attributes: []string{"*"}},
+	})
+	assert.False(t, filtered.HasChanges())
+	assert.Len(t, filtered.Ignored, 2)
+}
+
+func TestPlanDiffFilterIgnoredKeepsUnmatchedResources(t *testing.T) {
+	diff := testctx.DiffPlan(plan.LoadPlanFile(t, "testdata/plans/replace.json"))
+
+	filtered := diff.FilterIgnored([]testctx.IdempotencyIgnoreRule{
+		{Address: "module.example.time_static.*", Attributes: []string{"triggers.*"}},
+	})
+	assert.Equal(t, diff.Addresses(), filtered.Addresses(), "Deletes have no attributes and should not be ignored by attribute rules")
+	assert.Empty(t, filtered.Ignored)
+}
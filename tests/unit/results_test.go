@@ -0,0 +1,167 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/results"
+)
+
+// streamResults streams a recorded go test -json run through a collector and returns the report and printed output
+func streamResults(t *testing.T, input string) (*results.Report, string) {
+	collector := results.NewCollector()
+	var output bytes.Buffer
+	require.NoError(t, results.Stream(strings.NewReader(input), &output, collector))
+	return collector.Report(), output.String()
+}
+
+func TestResultsAggregatesEvents(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "results", "run.jsonl"))
+	require.NoError(t, err)
+
+	report, output := streamResults(t, string(content))
+
+	// The output reads like go test -v
+	assert.Contains(t, output, "--- PASS: TestBasicOutput (12.00s)\n")
+	assert.Contains(t, output, "FAIL\tgithub.com/org/module/tests/common\t30.2s\n")
+
+	require.Len(t, report.Examples, 2)
+	basic := report.Examples[0]
+	assert.Equal(t, "basic", basic.Name)
+	assert.Equal(t, results.StatusPass, basic.Status)
+	assert.Equal(t, 12.5, basic.Duration)
+	require.Len(t, basic.Tests, 2)
+	assert.Equal(t, results.Test{
+		Name:     "TestBasicOutput",
+		Status:   results.StatusPass,
+		Duration: 12,
+		Output:   "=== RUN   TestBasicOutput\n    basic_test.go:20: Applying basic\n--- PASS: TestBasicOutput (12.00s)\n",
+	}, *basic.Tests[0])
+	assert.Equal(t, results.StatusSkip, basic.Tests[1].Status)
+
+	common := report.Examples[1]
+	assert.Equal(t, results.StatusFail, common.Status)
+	assert.Equal(t, "TestAllExamples/Example_basic", common.Tests[1].Name)
+	assert.Contains(t, common.Tests[1].Output, "Failed to apply basic")
+
+	assert.Equal(t, results.Summary{Examples: 2, Tests: 4, Passed: 1, Failed: 2, Skipped: 1, Duration: 42.7}, report.Summary)
+	assert.True(t, report.Failed())
+	assert.Equal(t, []string{"common: TestAllExamples", "common: TestAllExamples/Example_basic"}, report.FailedTests())
+
+	assert.Equal(t, `EXAMPLE  STATUS  TESTS  PASS  FAIL  SKIP  DURATION
+basic    PASS    2      1     0     1     12.5s
+common   FAIL    2      0     2     0     30.2s
+TOTAL            4      1     2     1     42.7s
+
+Failed tests:
+  common: TestAllExamples
+  common: TestAllExamples/Example_basic`, report.String())
+}
+
+func TestResultsUnfinishedAndNonJSONOutput(t *testing.T) {
+	report, output := streamResults(t, `# github.com/org/module/tests/basic
+tests/basic/basic_test.go:5:2: undefined: foo
+{"Action":"run","Package":"github.com/org/module/tests/advanced","Test":"TestTimeout"}
+{"Action":"output","Package":"github.com/org/module/tests/advanced","Test":"TestTimeout","Output":"panic: test timed out after 10m0s\n"}
+`)
+
+	assert.Contains(t, output, "undefined: foo\n", "Lines that are not events should be printed as is")
+	require.Len(t, report.Examples, 1)
+	assert.Equal(t, results.StatusFail, report.Examples[0].Tests[0].Status, "Tests that never finished should fail")
+	assert.Equal(t, results.StatusFail, report.Examples[0].Status)
+
+	// Examples of a version matrix run are labelled with their version
+	report.Examples[0].TerraformVersion = "1.5.7"
+	report = results.NewReport(report.Examples)
+	assert.Contains(t, report.String(), "advanced@1.5.7")
+}
+
+func TestResultsJUnit(t *testing.T) {
+	report, _ := streamResults(t, `{"Action":"run","Package":"github.com/org/module/tests/basic","Test":"TestBasic"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"    stages.go:90: tftest-phase: init took 2.000s\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"    logger.go:66: Error: Invalid value for variable\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"    stages.go:90: tftest-phase: apply took 10.500s\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"    runner.go:133: Failed to apply basic: exit status 1\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"--- FAIL: TestBasic (12.50s)\n"}
+{"Action":"fail","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Elapsed":12.5}
+{"Action":"run","Package":"github.com/org/module/tests/basic","Test":"TestSkipped"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestSkipped","Output":"    basic_test.go:30: Skipping basic: no persisted context\n"}
+{"Action":"skip","Package":"github.com/org/module/tests/basic","Test":"TestSkipped","Elapsed":0}
+{"Action":"fail","Package":"github.com/org/module/tests/basic","Elapsed":13}
+{"Action":"output","Package":"github.com/org/module/tests/common","Output":"# github.com/org/module/tests/common\n"}
+{"Action":"fail","Package":"github.com/org/module/tests/common","Elapsed":0.1}
+`)
+
+	assert.Equal(t, []results.Phase{{Name: "init", Duration: 2}, {Name: "apply", Duration: 10.5}}, report.Examples[0].Tests[0].Phases)
+
+	content, err := report.JUnit()
+	require.NoError(t, err)
+	xml := string(content)
+
+	assert.Contains(t, xml, `<testsuites name="tftest" tests="3" failures="2" skipped="1" time="13.100">`)
+	assert.Contains(t, xml, `<testsuite name="tests/basic" tests="2" failures="1" skipped="1" time="13.000">`)
+	assert.Contains(t, xml, `<property name="terraform.phase.init.duration" value="2.000"></property>`)
+	assert.Contains(t, xml, `<property name="terraform.phase.apply.duration" value="10.500"></property>`)
+	assert.Contains(t, xml, `<failure message="Error: Invalid value for variable" type="failure">    logger.go:66: Error: Invalid value for variable&#xA;    runner.go:133: Failed to apply basic: exit status 1</failure>`)
+	assert.Contains(t, xml, `<skipped message="Skipping basic: no persisted context"></skipped>`)
+	assert.Contains(t, xml, `<system-out>    stages.go:90: tftest-phase: init took 2.000s`)
+
+	// A package that failed without running tests is reported as a failed testcase
+	assert.Contains(t, xml, `<testsuite name="tests/common" tests="1" failures="1" skipped="0" time="0.100">`)
+	assert.Contains(t, xml, `<testcase name="[no tests ran]" classname="tests/common" time="0.100">`)
+	assert.NotContains(t, xml, "<properties></properties>")
+}
+
+func TestResultsHTML(t *testing.T) {
+	report, _ := streamResults(t, `{"Action":"run","Package":"github.com/org/module/tests/basic","Test":"TestBasic"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"[2025-01-01 10:00:00] [Benchmark] INFO: Benchmark completed: apply <basic> (2.5s)\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"[2025-01-01 10:00:03] [Benchmark] ERROR: Benchmark failed: destroy (1m30s): timeout\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"    stages.go:90: tftest-phase: apply took 10.000s\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"    idempotency.go:178: Idempotency test failed for basic: Terraform plan would change 1 resource(s):\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"          random_id.main (update)\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"              keepers.time: \"a\" => \"b\"\n"}
+{"Action":"output","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Output":"--- FAIL: TestBasic (12.50s)\n"}
+{"Action":"fail","Package":"github.com/org/module/tests/basic","Test":"TestBasic","Elapsed":12.5}
+{"Action":"fail","Package":"github.com/org/module/tests/basic","Elapsed":13}
+`)
+
+	test := report.Examples[0].Tests[0]
+	assert.Equal(t, []string{"Idempotency test failed for basic: Terraform plan would change 1 resource(s):\n  random_id.main (update)\n      keepers.time: \"a\" => \"b\""}, test.PlanDiffs)
+	assert.Equal(t, []results.Benchmark{
+		{Name: "apply <basic>", Duration: 2.5, Success: true},
+		{Name: "destroy", Duration: 90, Error: "timeout"},
+	}, test.Benchmarks)
+
+	var html bytes.Buffer
+	require.NoError(t, report.HTML(&html, results.HTMLOptions{Title: "Nightly"}))
+	page := html.String()
+
+	assert.Contains(t, page, "<title>Nightly</title>")
+	assert.Contains(t, page, `<details class="example" data-status="fail" open>`)
+	assert.Contains(t, page, `<button type="button" class="filter" data-filter="fail">Failed</button>`)
+	assert.Contains(t, page, `keepers.time: &#34;a&#34; =&gt; &#34;b&#34;`, "Plan diffs should be escaped")
+	assert.Contains(t, page, `<td>apply &lt;basic&gt;</td><td>2.5s</td><td>ok</td>`)
+	assert.Contains(t, page, `<td>destroy</td><td>1m30s</td><td>failed: timeout</td>`)
+	assert.Contains(t, page, `style="width: 100.00%" title="apply: 10.0s"`)
+	assert.NotRegexp(t, `(src|href)="?(https?:)?//`, page, "The report should not load external assets")
+
+	// The report reads back from both the --report-json format and the go test -json stream
+	content, err := os.ReadFile(filepath.Join("testdata", "results", "run.jsonl"))
+	require.NoError(t, err)
+	fromEvents, err := results.ReadReport(bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, 4, fromEvents.Summary.Tests)
+
+	encoded, err := json.Marshal(report)
+	require.NoError(t, err)
+	fromJSON, err := results.ReadReport(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	assert.Equal(t, report, fromJSON)
+}
@@ -74,11 +74,8 @@ func TestRunCustomTestsInRunner(t *testing.T) {
 	assert.Equal(t, 2, len(tested))
 }
 
-// Note: The following functions are difficult to unit test without mocking:
-// - RunExample (requires terraform.InitAndApply)
+// Note: RunExample and RunSingleExample are covered against the fake executor in fake_test.go. The following functions
+// depend on discovering examples on disk and are covered by the functional tests:
 // - RunAllExamplesWithTests (depends on RunAllExamples)
 // - DiscoverAndRunAllTests (depends on os.ReadDir and RunAllExamples)
 // - RunAllExamples (depends on os.ReadDir and RunExample)
-// - RunSingleExample (depends on os.Stat and RunExample)
-//
-// These would typically be tested with integration tests or with mocking.
@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// snapshotStateJSON is a state with volatile ids and timestamps
+const snapshotStateJSON = `{
+  "format_version": "1.0",
+  "values": {
+    "root_module": {
+      "child_modules": [{
+        "address": "module.example",
+        "resources": [
+          {
+            "address": "module.example.local_file.output",
+            "mode": "managed", "type": "local_file", "name": "output",
+            "values": {"id": "3f786850e387550fdab836ed7e6dc881de23001b", "content": "hello", "filename": "%s/output.txt"}
+          },
+          {
+            "address": "module.example.time_static.creation_time",
+            "mode": "managed", "type": "time_static", "name": "creation_time",
+            "values": {"id": "2024-05-01T10:00:00Z", "rfc3339": "2024-05-01T10:00:00Z", "unix": 1714557600}
+          }
+        ]
+      }]
+    }
+  }
+}`
+
+// chdir changes the working directory for the duration of the test
+func chdir(t *testing.T, dir string) {
+	previous, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(previous) })
+}
+
+// snapshotContext returns a context in workspace whose fake executor serves the given output content and a fixed state
+func snapshotContext(workspace, content string) testctx.TestContext {
+	ctx := testctx.Run(workspace, testctx.TestConfig{
+		Name:           "basic",
+		SnapshotIgnore: []string{"module.example.time_static.creation_time.*"},
+	})
+	ctx.Executor = fake.New().
+		On(fake.CommandOutput, fake.Response{Stdout: `{
+  "output_content": {"sensitive": false, "type": "string", "value": "` + content + `"},
+  "creation_timestamp": {"sensitive": false, "type": "string", "value": "2024-05-01T10:00:00Z"}
+}`}).
+		On(fake.CommandShowState, fake.Response{Stdout: strings.ReplaceAll(snapshotStateJSON, "%s", workspace)})
+	return ctx
+}
+
+func TestAssertMatchesSnapshot(t *testing.T) {
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "")
+	workspace := t.TempDir()
+	chdir(t, t.TempDir())
+
+	// A missing snapshot fails unless snapshots are updated
+	ft := fake.Run(t, func(ft *fake.T) {
+		assertions.AssertMatchesSnapshot(ft, snapshotContext(workspace, "hello"), "basic")
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), "does not exist, run tftest run --update-snapshots to create it")
+	assert.NoFileExists(t, testctx.SnapshotFile("basic"))
+
+	// Updating creates the snapshot
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "true")
+	assertions.AssertMatchesSnapshot(t, snapshotContext(workspace, "hello"), "basic")
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "")
+	content, err := os.ReadFile(filepath.Join(testctx.SnapshotDir, "basic.json"))
+	require.NoError(t, err)
+	snapshot := string(content)
+	assert.Contains(t, snapshot, `"creation_timestamp": "<volatile>"`)
+	assert.Contains(t, snapshot, `"id": "<volatile>"`)
+	assert.Contains(t, snapshot, `"filename": "<workspace>/output.txt"`)
+	assert.Contains(t, snapshot, `"unix": "<volatile>"`, "Configured rules should be applied")
+	assert.NotContains(t, snapshot, "2024-05-01")
+
+	// Volatile values and the workspace path may change between runs
+	assertions.AssertMatchesSnapshot(t, snapshotContext(t.TempDir(), "hello"), "basic")
+
+	// Other changes fail with a diff
+	ft = fake.Run(t, func(ft *fake.T) {
+		assertions.AssertMatchesSnapshot(ft, snapshotContext(workspace, "changed"), "basic")
+	})
+	assert.True(t, ft.Failed())
+	assert.Contains(t, failureMessages(ft), `-    "output_content": "hello"`)
+	assert.Contains(t, failureMessages(ft), `+    "output_content": "changed"`)
+	assert.Contains(t, failureMessages(ft), "--update-snapshots")
+
+	// Updating rewrites the snapshot
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "true")
+	assertions.AssertMatchesSnapshot(t, snapshotContext(workspace, "changed"), "basic")
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "")
+	assertions.AssertMatchesSnapshot(t, snapshotContext(workspace, "changed"), "basic")
+}
+
+func TestAssertMatchesSnapshotPlan(t *testing.T) {
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "")
+	planJSON, err := os.ReadFile(filepath.Join("testdata", "plans", "create.json"))
+	require.NoError(t, err)
+	plan, err := terraform.ParsePlanJSON(string(planJSON))
+	require.NoError(t, err)
+
+	chdir(t, t.TempDir())
+	ctx := testctx.NewTestContext("basic", nil)
+	ctx.Plan = plan
+
+	t.Setenv("TERRATEST_UPDATE_SNAPSHOTS", "true")
+	assertions.AssertMatchesSnapshot(t, ctx, "basic-plan")
+	assert.NoFileExists(t, testctx.SnapshotFile("basic-plan"), "Plans are not compared with the snapshot of the applied example")
+	content, err := os.ReadFile(testctx.PlanSnapshotFile("basic-plan"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"plan": {`)
+	assert.Contains(t, string(content), `"create"`)
+	assert.Contains(t, string(content), `"content_sha256": "<computed>"`)
+	assert.NotContains(t, string(content), `"state"`)
+}
@@ -0,0 +1,111 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+)
+
+func TestParseStages(t *testing.T) {
+	stages, err := testctx.ParseStages("validate, Destroy")
+	require.NoError(t, err)
+	assert.Equal(t, []testctx.Stage{testctx.StageValidate, testctx.StageDestroy}, stages)
+
+	stages, err = testctx.ParseStages("")
+	require.NoError(t, err)
+	assert.Empty(t, stages)
+
+	_, err = testctx.ParseStages("apply,teardown")
+	assert.ErrorContains(t, err, "teardown")
+}
+
+func TestStageEnabled(t *testing.T) {
+	defer os.Unsetenv("TERRATEST_SKIP_STAGES")
+	defer os.Unsetenv("TERRATEST_ONLY_STAGES")
+
+	// All stages run by default
+	os.Unsetenv("TERRATEST_SKIP_STAGES")
+	os.Unsetenv("TERRATEST_ONLY_STAGES")
+	for _, stage := range testctx.Stages {
+		assert.True(t, testctx.StageEnabled(stage), "Stage %s should be enabled by default", stage)
+	}
+
+	os.Setenv("TERRATEST_SKIP_STAGES", "destroy")
+	assert.False(t, testctx.StageEnabled(testctx.StageDestroy))
+	assert.True(t, testctx.StageEnabled(testctx.StageApply))
+
+	// TERRATEST_ONLY_STAGES takes precedence over TERRATEST_SKIP_STAGES
+	os.Setenv("TERRATEST_ONLY_STAGES", "validate")
+	assert.True(t, testctx.StageEnabled(testctx.StageValidate))
+	assert.False(t, testctx.StageEnabled(testctx.StageApply))
+	assert.False(t, testctx.StageEnabled(testctx.StageDestroy))
+}
+
+func TestRunStage(t *testing.T) {
+	defer os.Unsetenv("TERRATEST_SKIP_STAGES")
+	os.Setenv("TERRATEST_SKIP_STAGES", "validate")
+
+	ran := false
+	assert.False(t, testctx.RunStage(t, testctx.StageValidate, func() { ran = true }))
+	assert.False(t, ran, "Skipped stage should not run")
+
+	assert.True(t, testctx.RunStage(t, testctx.StageApply, func() { ran = true }))
+	assert.True(t, ran, "Enabled stage should run")
+}
+
+func TestSaveAndLoadContext(t *testing.T) {
+	examplePath := t.TempDir()
+	workspace := t.TempDir()
+	config := testctx.TestConfig{Name: "basic test"}
+
+	ctx := testctx.TestContext{
+		Config:        config,
+		ExamplePath:   examplePath,
+		Name:          config.Name,
+		WorkspacePath: workspace,
+		Terraform: &terraform.Options{
+			TerraformDir: workspace,
+			Vars:         map[string]interface{}{"region": "us-east-1"},
+			EnvVars:      map[string]string{"TF_LOG": "INFO"},
+		},
+	}
+	require.NoError(t, testctx.SaveContext(ctx))
+	assert.Equal(t, filepath.Join(examplePath, ".tftest", "basic_test.json"), testctx.ContextFile(examplePath, config.Name))
+	assert.FileExists(t, testctx.ContextFile(examplePath, config.Name))
+
+	loaded, found, err := testctx.LoadContext(examplePath, config)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, workspace, loaded.WorkspacePath)
+	assert.Equal(t, workspace, loaded.Terraform.TerraformDir)
+	assert.Equal(t, "us-east-1", loaded.Terraform.Vars["region"])
+	assert.Equal(t, "INFO", loaded.Terraform.EnvVars["TF_LOG"])
+	assert.Equal(t, config, loaded.Config)
+
+	require.NoError(t, testctx.RemoveContext(loaded))
+	_, found, err = testctx.LoadContext(examplePath, config)
+	require.NoError(t, err)
+	assert.False(t, found, "Removed context should not be found")
+}
+
+func TestLoadContextWithMissingWorkspace(t *testing.T) {
+	examplePath := t.TempDir()
+	config := testctx.TestConfig{Name: "basic"}
+
+	ctx := testctx.TestContext{
+		ExamplePath: examplePath,
+		Name:        config.Name,
+		Terraform:   &terraform.Options{TerraformDir: filepath.Join(examplePath, "missing")},
+	}
+	require.NoError(t, testctx.SaveContext(ctx))
+
+	_, found, err := testctx.LoadContext(examplePath, config)
+	require.NoError(t, err)
+	assert.False(t, found, "Context with a removed workspace should not be resumed")
+}
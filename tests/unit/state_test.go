@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+)
+
+// loadStateFixture parses the recorded state fixture
+func loadStateFixture(t *testing.T) *testctx.State {
+	content, err := os.ReadFile("testdata/state/state.json")
+	require.NoError(t, err)
+
+	state, err := testctx.ParseState(string(content))
+	require.NoError(t, err)
+	return state
+}
+
+func TestParseState(t *testing.T) {
+	state := loadStateFixture(t)
+
+	assert.Equal(t, "1.9.0", state.TerraformVersion)
+	assert.Len(t, state.Resources, 4)
+
+	bucket, exists := state.Resource(`module.x.aws_s3_bucket.b["logs"]`)
+	require.True(t, exists)
+	assert.Equal(t, "module.x", bucket.ModulePath)
+	assert.Equal(t, "managed", bucket.Mode)
+	assert.Equal(t, "aws_s3_bucket", bucket.Type)
+	assert.Equal(t, "b", bucket.Name)
+	assert.Equal(t, "logs", bucket.IndexKey)
+
+	queue, exists := state.Resource("module.x.module.y.aws_sqs_queue.q[0]")
+	require.True(t, exists)
+	assert.Equal(t, "module.x.module.y", queue.ModulePath)
+	assert.Equal(t, 0, queue.IndexKey)
+
+	data, exists := state.Resource("data.aws_caller_identity.current")
+	require.True(t, exists)
+	assert.Equal(t, "", data.ModulePath)
+	assert.Nil(t, data.IndexKey)
+}
+
+func TestStateResourceAttribute(t *testing.T) {
+	state := loadStateFixture(t)
+
+	bucket, _ := state.Resource(`module.x.aws_s3_bucket.b["logs"]`)
+	value, found := bucket.Attribute("versioning.0.enabled")
+	assert.True(t, found)
+	assert.Equal(t, true, value)
+
+	_, found = bucket.Attribute("versioning.1.enabled")
+	assert.False(t, found)
+
+	queue, _ := state.Resource("module.x.module.y.aws_sqs_queue.q[0]")
+	value, found = queue.Attribute("visibility_timeout_seconds")
+	assert.True(t, found)
+	assert.EqualValues(t, 30, value)
+}
+
+func TestStateResourcesOfType(t *testing.T) {
+	state := loadStateFixture(t)
+
+	assert.Len(t, state.ResourcesOfType("aws_s3_bucket"), 2)
+	assert.Len(t, state.ResourcesOfType("aws_sqs_queue"), 1)
+	assert.Empty(t, state.ResourcesOfType("aws_caller_identity"), "Data sources should not be counted")
+}
+
+func TestParseEmptyState(t *testing.T) {
+	state, err := testctx.ParseState(`{"format_version":"1.0"}`)
+	require.NoError(t, err)
+	assert.Empty(t, state.Resources)
+
+	_, err = testctx.ParseState("not json")
+	assert.Error(t, err)
+}
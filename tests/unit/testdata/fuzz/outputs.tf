@@ -0,0 +1,9 @@
+output "bucket_name" {
+  description = "Name of the bucket"
+  value       = var.bucket_name
+}
+
+output "settings" {
+  value     = var.settings
+  sensitive = true
+}
@@ -0,0 +1,33 @@
+variable "bucket_name" {
+  description = "Name of the bucket"
+  type        = string
+
+  validation {
+    condition     = length(var.bucket_name) <= 63 && can(regex("^[a-z0-9-]+$", var.bucket_name))
+    error_message = "The bucket name must be lowercase and at most 63 characters."
+  }
+}
+
+variable "retention_days" {
+  type     = number
+  default  = 30
+  nullable = false
+}
+
+variable "regions" {
+  type    = list(string)
+  default = ["us-east-1"]
+}
+
+variable "settings" {
+  type = object({
+    enabled = bool
+    prefix  = optional(string)
+  })
+  default   = { enabled = true }
+  sensitive = true
+}
+
+variable "anything" {
+  default = null
+}
@@ -0,0 +1,152 @@
+package unit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// createUpgradeModule creates a git repository holding a module with a single example and a v1 tag
+func createUpgradeModule(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	moduleRoot := t.TempDir()
+	exampleDir := filepath.Join(moduleRoot, "examples", "basic")
+	require.NoError(t, os.MkdirAll(exampleDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(moduleRoot, "main.tf"), []byte("output \"name\" {\n  value = \"v1\"\n}\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(exampleDir, "main.tf"), []byte("module \"main\" {\n  source = \"../../\"\n}\n"), 0644))
+
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "v1"},
+		{"tag", "v1"},
+	} {
+		output, err := exec.Command("git", append([]string{"-C", moduleRoot}, args...)...).CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	return moduleRoot
+}
+
+func TestRunUpgradeCheckUnknownRef(t *testing.T) {
+	moduleRoot := t.TempDir()
+	writeFiles(t, moduleRoot, map[string]string{
+		"main.tf":                "",
+		"examples/basic/main.tf": "module \"main\" {\n  source = \"../../\"\n}\n",
+	})
+	exampleDir := filepath.Join(moduleRoot, "examples", "basic")
+
+	result, err := testctx.RunUpgradeCheckE(t, exampleDir, "does-not-exist", "", testctx.TestConfig{Name: "basic"})
+	defer result.Cleanup(t)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does-not-exist")
+}
+
+func TestRunUpgradeCheckModuleRootFromSources(t *testing.T) {
+	moduleRoot := createUpgradeModule(t)
+
+	// The module root is taken from the module source, not from the depth of the example
+	exampleDir := filepath.Join(t.TempDir(), "test", "fixtures", "basic")
+	source, err := filepath.Rel(exampleDir, moduleRoot)
+	require.NoError(t, err)
+	writeFiles(t, exampleDir, map[string]string{"main.tf": "module \"main\" {\n  source = \"" + filepath.ToSlash(source) + "\"\n}\n"})
+
+	result, err := testctx.RunUpgradeCheckE(t, exampleDir, "v1", "does-not-exist", testctx.TestConfig{Name: "basic"})
+	defer result.Cleanup(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to check out ref does-not-exist", "v1 should be checked out from the module's repository")
+
+	// An example without a local module source cannot be upgraded
+	standalone := t.TempDir()
+	writeFiles(t, standalone, map[string]string{"main.tf": ""})
+	result, err = testctx.RunUpgradeCheckE(t, standalone, "v1", "", testctx.TestConfig{Name: "standalone"})
+	defer result.Cleanup(t)
+	assert.ErrorContains(t, err, "does not reference a local module source")
+}
+
+func TestRunUpgradeCheckCleansUpWorktrees(t *testing.T) {
+	moduleRoot := createUpgradeModule(t)
+	exampleDir := filepath.Join(moduleRoot, "examples", "basic")
+
+	// The from ref is checked out before the to ref fails to resolve
+	result, err := testctx.RunUpgradeCheckE(t, exampleDir, "v1", "does-not-exist", testctx.TestConfig{Name: "basic"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to check out ref does-not-exist")
+
+	worktrees, err := exec.Command("git", "-C", moduleRoot, "worktree", "list").Output()
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(worktrees)), "\n"), 2, "v1 should be checked out in a worktree")
+
+	result.Cleanup(t)
+
+	worktrees, err = exec.Command("git", "-C", moduleRoot, "worktree", "list").Output()
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(worktrees)), "\n"), 1, "Cleanup should remove the worktree")
+}
+
+// writeUpgradeFixture writes a module whose example reads a template of the module root, and a v1 checkout of it
+func writeUpgradeFixture(t *testing.T) (string, string) {
+	moduleRoot := t.TempDir()
+	writeFiles(t, moduleRoot, map[string]string{
+		"main.tf":                "",
+		"config.tpl":             "${name}\n",
+		"examples/basic/main.tf": "module \"main\" {\n  source = \"../../\"\n}\n\nlocals {\n  config = file(\"../../config.tpl\")\n}\n",
+	})
+	v1 := t.TempDir()
+	writeFiles(t, v1, map[string]string{"main.tf": ""})
+	return filepath.Join(moduleRoot, "examples", "basic"), v1
+}
+
+func TestRunUpgradeCheckKeepsRelativeReferences(t *testing.T) {
+	exampleDir, v1 := writeUpgradeFixture(t)
+	planJSON, err := os.ReadFile(filepath.Join("testdata", "plans", "create.json"))
+	require.NoError(t, err)
+	executor := fake.New().On(fake.CommandShowPlan, fake.Response{Stdout: string(planJSON)})
+
+	result, err := testctx.RunUpgradeCheckE(t, exampleDir, v1, "", testctx.TestConfig{Name: "basic", Executor: executor})
+	defer result.Cleanup(t)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandApply, fake.CommandInit, fake.CommandPlan, fake.CommandShowPlan}, executor.Commands())
+	assert.FileExists(t, filepath.Join(result.Terraform.TerraformDir, "..", "..", "config.tpl"), "Files outside the example should be copied with it")
+	assert.NoDirExists(t, filepath.Join(exampleDir, ".terraform"), "The example itself should not be modified")
+
+	// The applied configuration is destroyed: the sources point back at v1 and are initialized again
+	workspaceMain, err := os.ReadFile(filepath.Join(result.Terraform.TerraformDir, "main.tf"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(workspaceMain), filepath.ToSlash(v1), "The plan should use the working tree")
+	result.Cleanup(t)
+	commands := executor.Commands()
+	assert.Equal(t, []string{fake.CommandInit, fake.CommandDestroy}, commands[len(commands)-2:])
+}
+
+func TestRunUpgradeCheckPathRefs(t *testing.T) {
+	moduleRoot := createUpgradeModule(t)
+	exampleDir := filepath.Join(moduleRoot, "examples", "basic")
+
+	// A directory named like the ref does not shadow it
+	chdir(t, t.TempDir())
+	require.NoError(t, os.Mkdir("v1", 0755))
+	result, err := testctx.RunUpgradeCheckE(t, exampleDir, "v1", "does-not-exist", testctx.TestConfig{Name: "basic"})
+	defer result.Cleanup(t)
+	require.Error(t, err)
+	worktrees, err := exec.Command("git", "-C", moduleRoot, "worktree", "list").Output()
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(worktrees)), "\n"), 2, "v1 should be checked out as a git ref")
+
+	// Paths must be written as such
+	result, err = testctx.RunUpgradeCheckE(t, exampleDir, "./missing", "", testctx.TestConfig{Name: "basic"})
+	defer result.Cleanup(t)
+	assert.ErrorContains(t, err, "module path ./missing is not a directory")
+}
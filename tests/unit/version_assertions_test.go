@@ -0,0 +1,140 @@
+package unit
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/internal/tfconfig"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/assertions"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx/fake"
+)
+
+// versionContext returns a context for dir whose executor reports the given version JSON
+func versionContext(dir string, versionJSON string) testctx.TestContext {
+	ctx := testctx.Run(dir, testctx.TestConfig{Name: "versions"})
+	ctx.Executor = fake.New().On(fake.CommandVersion, fake.Response{Stdout: versionJSON})
+	return ctx
+}
+
+// writeVersionedModule writes an example calling a local module with version constraints
+func writeVersionedModule(t *testing.T) string {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"versions.tf": `terraform {
+  required_version = ">= 1.5, < 2.0"
+
+  required_providers {
+    aws = {
+      source                = "hashicorp/aws"
+      version               = "~> 5.0"
+      configuration_aliases = [aws.replica]
+    }
+    random = ">= 3.5"
+  }
+}
+`,
+		"examples/basic/main.tf": `module "example" {
+  source = "../../"
+}
+
+module "registry" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+`,
+	})
+	return filepath.Join(root, "examples", "basic")
+}
+
+func TestAssertTerraformVersionConstraints(t *testing.T) {
+	ctx := versionContext(t.TempDir(), `{"terraform_version": "1.10.0"}`)
+
+	// 1.10.0 is newer than 1.9.0, which a lexical comparison gets wrong
+	for _, constraint := range []string{"1.9.0", ">= 1.9.0", "~> 1.5", ">= 1.3, < 2.0", "v1.10.0"} {
+		mockT := new(MockT)
+		assertions.AssertTerraformVersion(mockT, ctx, constraint)
+		assert.False(t, mockT.Failed(), "1.10.0 should satisfy %s", constraint)
+	}
+
+	for _, constraint := range []string{"1.11.0", "~> 1.9.0", "< 1.10", "not a constraint"} {
+		mockT := new(MockT)
+		assertions.AssertTerraformVersion(mockT, ctx, constraint)
+		assert.True(t, mockT.Failed(), "1.10.0 should not satisfy %s", constraint)
+	}
+}
+
+func TestAssertProviderVersion(t *testing.T) {
+	ctx := versionContext(t.TempDir(), `{
+  "terraform_version": "1.9.0",
+  "provider_selections": {"registry.terraform.io/hashicorp/aws": "5.31.0"}
+}`)
+
+	for _, provider := range []string{"aws", "hashicorp/aws", "registry.terraform.io/hashicorp/aws"} {
+		mockT := new(MockT)
+		assertions.AssertProviderVersion(mockT, ctx, provider, "~> 5.0")
+		assert.False(t, mockT.Failed(), provider)
+	}
+
+	mockT := new(MockT)
+	assertions.AssertProviderVersion(mockT, ctx, "aws", ">= 5.40")
+	assert.True(t, mockT.Failed())
+
+	mockT = new(MockT)
+	assertions.AssertProviderVersion(mockT, ctx, "random", ">= 3.0")
+	assert.True(t, mockT.Failed(), "A provider that is not installed should fail")
+}
+
+func TestAssertRequiredVersionSatisfied(t *testing.T) {
+	examplePath := writeVersionedModule(t)
+
+	ctx := versionContext(examplePath, `{
+  "terraform_version": "1.9.0",
+  "provider_selections": {
+    "registry.terraform.io/hashicorp/aws": "5.31.0",
+    "registry.terraform.io/hashicorp/random": "3.6.0"
+  }
+}`)
+	mockT := new(MockT)
+	assertions.AssertRequiredVersionSatisfied(mockT, ctx)
+	assert.False(t, mockT.Failed(), mockT.Messages)
+
+	ctx = versionContext(examplePath, `{
+  "terraform_version": "2.0.0",
+  "provider_selections": {
+    "registry.terraform.io/hashicorp/aws": "4.67.0",
+    "registry.terraform.io/hashicorp/random": "3.6.0"
+  }
+}`)
+	mockT = new(MockT)
+	assertions.AssertRequiredVersionSatisfied(mockT, ctx)
+	assert.True(t, mockT.Failed())
+}
+
+func TestLoadModuleTree(t *testing.T) {
+	examplePath := writeVersionedModule(t)
+
+	modules, err := tfconfig.LoadModuleTree(examplePath)
+	require.NoError(t, err)
+	require.Len(t, modules, 2, "Only local module calls should be followed")
+
+	example := modules[0]
+	assert.Equal(t, []tfconfig.ModuleCall{
+		{Name: "example", Source: "../../", Arguments: map[string][]string{}, Forwards: map[string]string{}},
+		{Name: "registry", Source: "terraform-aws-modules/vpc/aws", Arguments: map[string][]string{}, Forwards: map[string]string{}},
+	}, example.ModuleCalls)
+
+	module := modules[1]
+	assert.Equal(t, []string{">= 1.5, < 2.0"}, module.RequiredVersions)
+	require.Contains(t, module.RequiredProviders, "aws")
+	assert.Equal(t, "registry.terraform.io/hashicorp/aws", module.RequiredProviders["aws"].Address("aws"))
+	assert.Equal(t, []string{"~> 5.0"}, module.RequiredProviders["aws"].Versions)
+	assert.Equal(t, "registry.terraform.io/hashicorp/random", module.RequiredProviders["random"].Address("random"))
+	assert.Equal(t, []string{">= 3.5"}, module.RequiredProviders["random"].Versions)
+
+	_, err = tfconfig.LoadModule(t.TempDir())
+	assert.Error(t, err)
+}
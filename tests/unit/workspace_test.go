@@ -0,0 +1,85 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/caylent-solutions/terraform-terratest-framework/pkg/testctx"
+)
+
+// writeFiles creates the given files (relative path to content) below root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+}
+
+func TestIsolationEnabled(t *testing.T) {
+	defer os.Unsetenv("TERRATEST_DISABLE_PARALLEL_TESTS")
+
+	os.Setenv("TERRATEST_DISABLE_PARALLEL_TESTS", "false")
+	assert.True(t, testctx.IsolationEnabled(testctx.TestConfig{}), "Parallel runs should be isolated by default")
+	assert.False(t, testctx.IsolationEnabled(testctx.TestConfig{Workspace: testctx.WorkspaceInPlace}))
+
+	os.Setenv("TERRATEST_DISABLE_PARALLEL_TESTS", "true")
+	assert.False(t, testctx.IsolationEnabled(testctx.TestConfig{}), "Sequential runs should run in place by default")
+	assert.True(t, testctx.IsolationEnabled(testctx.TestConfig{Workspace: testctx.WorkspaceIsolated}))
+}
+
+func TestCreateIsolatedWorkspace(t *testing.T) {
+	moduleRoot := t.TempDir()
+	writeFiles(t, moduleRoot, map[string]string{
+		"main.tf":                            "module \"child\" {\n  source = \"./modules/child\"\n}\n",
+		"modules/child/main.tf":              "output \"name\" {\n  value = \"child\"\n}\n",
+		"examples/basic/main.tf":             "module \"main\" {\n  source = \"../../\"\n}\n",
+		"examples/basic/terraform.tfstate":   "{}",
+		"examples/basic/.terraform/lock":     "",
+		"examples/other/main.tf":             "",
+		"examples/other/.terraform.lock.hcl": "",
+		"tests/basic/module_test.go":         "package basic_test\n",
+		"config.tpl":                         "${name}\n",
+		"templates/user_data.sh":             "#!/bin/sh\n",
+	})
+
+	var workspaceExample string
+	t.Run("workspace", func(t *testing.T) {
+		workspaceExample = testctx.CreateIsolatedWorkspace(t, filepath.Join(moduleRoot, "examples", "basic"))
+
+		assert.NotEqual(t, filepath.Join(moduleRoot, "examples", "basic"), workspaceExample)
+		assert.Equal(t, filepath.Join("examples", "basic"), filepath.Join(filepath.Base(filepath.Dir(workspaceExample)), filepath.Base(workspaceExample)))
+		assert.FileExists(t, filepath.Join(workspaceExample, "main.tf"))
+		assert.FileExists(t, filepath.Join(workspaceExample, "..", "..", "main.tf"), "Referenced module should be copied")
+		assert.FileExists(t, filepath.Join(workspaceExample, "..", "..", "modules", "child", "main.tf"), "Nested local modules should be copied")
+		assert.NoFileExists(t, filepath.Join(workspaceExample, "terraform.tfstate"), "State should not be copied")
+		assert.NoDirExists(t, filepath.Join(workspaceExample, ".terraform"), "Terraform cache should not be copied")
+
+		// Only the example and the modules it references are copied, with the files they may read
+		root := filepath.Join(workspaceExample, "..", "..")
+		assert.FileExists(t, filepath.Join(root, "config.tpl"))
+		assert.FileExists(t, filepath.Join(root, "templates", "user_data.sh"))
+		assert.NoDirExists(t, filepath.Join(root, "examples", "other"), "Other examples should not be copied")
+		assert.NoDirExists(t, filepath.Join(root, "tests", "basic"), "Test packages should not be copied")
+	})
+
+	assert.NoDirExists(t, workspaceExample, "Workspace should be removed when the test finishes")
+}
+
+func TestCreateIsolatedWorkspaceStandaloneExample(t *testing.T) {
+	exampleDir := t.TempDir()
+	writeFiles(t, exampleDir, map[string]string{
+		"main.tf": "resource \"null_resource\" \"this\" {}\n",
+	})
+
+	workspace, workspaceExample, err := testctx.CreateIsolatedWorkspaceE(exampleDir)
+	require.NoError(t, err)
+	defer os.RemoveAll(workspace)
+
+	assert.Equal(t, workspace, workspaceExample, "An example without local sources should be copied on its own")
+	assert.FileExists(t, filepath.Join(workspaceExample, "main.tf"))
+}